@@ -0,0 +1,58 @@
+package entity
+
+// the actions are reduced by Adapter of the same Name
+
+// AddOneAction adds the entity if its id is not in the state.
+type AddOneAction[T any] struct {
+	Name   string
+	Entity T
+}
+
+// AddManyAction adds the entities whose ids are not in the state.
+type AddManyAction[T any] struct {
+	Name     string
+	Entities []T
+}
+
+// UpsertOneAction adds the entity or replaces the one of the same id.
+type UpsertOneAction[T any] struct {
+	Name   string
+	Entity T
+}
+
+// UpsertManyAction adds the entities or replaces the ones of the same ids.
+type UpsertManyAction[T any] struct {
+	Name     string
+	Entities []T
+}
+
+// UpdateOneAction replaces the entity of the id with the result of Update.
+// Update should return a new entity, not modify the given one.
+type UpdateOneAction[ID comparable, T any] struct {
+	Name   string
+	ID     ID
+	Update func(entity T) T
+}
+
+// SetAllAction replaces the entities in the state.
+type SetAllAction[T any] struct {
+	Name     string
+	Entities []T
+}
+
+// RemoveOneAction removes the entity of the id.
+type RemoveOneAction[ID comparable] struct {
+	Name string
+	ID   ID
+}
+
+// RemoveManyAction removes the entities of the ids.
+type RemoveManyAction[ID comparable] struct {
+	Name string
+	IDs  []ID
+}
+
+// RemoveAllAction removes all the entities.
+type RemoveAllAction struct {
+	Name string
+}
@@ -0,0 +1,377 @@
+package entity
+
+import (
+	"sort"
+
+	"github.com/rookiecj/go-store/immutable"
+	"github.com/rookiecj/go-store/store"
+)
+
+// EntityState is a normalized collection, the entities by id and the ids in order.
+// the zero EntityState is empty, EntityState is comparable by the pointers not to compute selectors again.
+type EntityState[ID comparable, T any] struct {
+	IDs      *immutable.Vector[ID]
+	Entities *immutable.Map[ID, T]
+}
+
+func (c EntityState[ID, T]) StateInterface() {}
+
+// Len returns the number of the entities.
+func (c EntityState[ID, T]) Len() int {
+	return c.IDs.Len()
+}
+
+// Get returns the entity of the id.
+func (c EntityState[ID, T]) Get(id ID) (T, bool) {
+	return c.Entities.Get(id)
+}
+
+// All returns the entities in the order of IDs.
+func (c EntityState[ID, T]) All() []T {
+	entities := make([]T, 0, c.IDs.Len())
+	c.IDs.Range(func(index int, id ID) bool {
+		entity, _ := c.Entities.Get(id)
+		entities = append(entities, entity)
+		return true
+	})
+	return entities
+}
+
+// Adapter reduces EntityState of the entities with the name.
+type Adapter[ID comparable, T any] struct {
+	// Name is matched with Name of the actions
+	Name string
+	// SelectID returns the id of the entity
+	SelectID func(entity T) ID
+	// Less sorts the ids by the entities, in the order added if nil.
+	// the ids are found by binary search if sorted, by a scan to remove or change if not.
+	Less func(a, b T) bool
+}
+
+// NewAdapter creates an adapter with the name of the actions and the id of the entity.
+func NewAdapter[ID comparable, T any](name string, selectID func(entity T) ID) *Adapter[ID, T] {
+	return &Adapter[ID, T]{
+		Name:     name,
+		SelectID: selectID,
+	}
+}
+
+// Initial returns EntityState of the entities.
+func (c *Adapter[ID, T]) Initial(entities ...T) EntityState[ID, T] {
+	return c.SetAll(EntityState[ID, T]{}, entities...)
+}
+
+// AddOne adds the entity if its id is not in the state.
+func (c *Adapter[ID, T]) AddOne(state EntityState[ID, T], entity T) EntityState[ID, T] {
+	return c.put(state, []T{entity}, false)
+}
+
+// AddMany adds the entities whose ids are not in the state.
+func (c *Adapter[ID, T]) AddMany(state EntityState[ID, T], entities ...T) EntityState[ID, T] {
+	return c.put(state, entities, false)
+}
+
+// UpsertOne adds the entity or replaces the one of the same id.
+func (c *Adapter[ID, T]) UpsertOne(state EntityState[ID, T], entity T) EntityState[ID, T] {
+	return c.put(state, []T{entity}, true)
+}
+
+// UpsertMany adds the entities or replaces the ones of the same ids.
+func (c *Adapter[ID, T]) UpsertMany(state EntityState[ID, T], entities ...T) EntityState[ID, T] {
+	return c.put(state, entities, true)
+}
+
+// UpdateOne replaces the entity of the id with the result of update, nothing if not in the state.
+// the entity is moved if update changes the id, replacing the one of the new id.
+func (c *Adapter[ID, T]) UpdateOne(state EntityState[ID, T], id ID, update func(entity T) T) EntityState[ID, T] {
+	entity, ok := state.Entities.Get(id)
+	if !ok {
+		return state
+	}
+	updated := update(entity)
+	if newID := c.SelectID(updated); newID != id {
+		if c.Less != nil {
+			return c.put(c.RemoveMany(state, id, newID), []T{updated}, true)
+		}
+		// in the place of the old one
+		return EntityState[ID, T]{
+			IDs:      c.replaceID(state.IDs, id, newID, state.Entities.Has(newID)),
+			Entities: state.Entities.Delete(id).Set(newID, updated),
+		}
+	}
+	return c.put(state, []T{updated}, true)
+}
+
+// SetAll replaces the entities in the state.
+func (c *Adapter[ID, T]) SetAll(state EntityState[ID, T], entities ...T) EntityState[ID, T] {
+	return c.put(EntityState[ID, T]{}, entities, true)
+}
+
+// RemoveOne removes the entity of the id.
+func (c *Adapter[ID, T]) RemoveOne(state EntityState[ID, T], id ID) EntityState[ID, T] {
+	return c.RemoveMany(state, id)
+}
+
+// RemoveMany removes the entities of the ids.
+func (c *Adapter[ID, T]) RemoveMany(state EntityState[ID, T], ids ...ID) EntityState[ID, T] {
+	entities := state.Entities
+	var removed []ID
+	for _, id := range ids {
+		if entities.Has(id) {
+			entities = entities.Delete(id)
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) == 0 {
+		return state
+	}
+
+	return EntityState[ID, T]{
+		IDs:      c.deleteIDs(state.IDs, state.Entities, removed),
+		Entities: entities,
+	}
+}
+
+// RemoveAll removes all the entities.
+func (c *Adapter[ID, T]) RemoveAll(state EntityState[ID, T]) EntityState[ID, T] {
+	if state.Len() == 0 {
+		return state
+	}
+	return EntityState[ID, T]{}
+}
+
+// Reduce is the reducer of the actions with Name of the adapter.
+func (c *Adapter[ID, T]) Reduce(state EntityState[ID, T], action store.Action) (EntityState[ID, T], error) {
+	switch reified := action.(type) {
+	case *AddOneAction[T]:
+		if reified.Name == c.Name {
+			return c.AddOne(state, reified.Entity), nil
+		}
+	case *AddManyAction[T]:
+		if reified.Name == c.Name {
+			return c.AddMany(state, reified.Entities...), nil
+		}
+	case *UpsertOneAction[T]:
+		if reified.Name == c.Name {
+			return c.UpsertOne(state, reified.Entity), nil
+		}
+	case *UpsertManyAction[T]:
+		if reified.Name == c.Name {
+			return c.UpsertMany(state, reified.Entities...), nil
+		}
+	case *UpdateOneAction[ID, T]:
+		if reified.Name == c.Name {
+			return c.UpdateOne(state, reified.ID, reified.Update), nil
+		}
+	case *SetAllAction[T]:
+		if reified.Name == c.Name {
+			return c.SetAll(state, reified.Entities...), nil
+		}
+	case *RemoveOneAction[ID]:
+		if reified.Name == c.Name {
+			return c.RemoveOne(state, reified.ID), nil
+		}
+	case *RemoveManyAction[ID]:
+		if reified.Name == c.Name {
+			return c.RemoveMany(state, reified.IDs...), nil
+		}
+	case *RemoveAllAction:
+		if reified.Name == c.Name {
+			return c.RemoveAll(state), nil
+		}
+	}
+	return state, nil
+}
+
+// mergeRatio is the number of the ids for an entity inserted one by one, merged in a pass if more added
+const mergeRatio = 32
+
+// put adds the entities, replacing the ones of the same ids if replace
+func (c *Adapter[ID, T]) put(state EntityState[ID, T], entities []T, replace bool) EntityState[ID, T] {
+	ids := state.IDs
+	changed := state.Entities
+	// added in order, by the index of the id
+	var added []T
+	addedAt := map[ID]int{}
+	for _, entity := range entities {
+		id := c.SelectID(entity)
+		if idx, ok := addedAt[id]; ok {
+			if replace {
+				added[idx] = entity
+				changed = changed.Set(id, entity)
+			}
+			continue
+		}
+
+		switch {
+		case !changed.Has(id):
+			addedAt[id] = len(added)
+			added = append(added, entity)
+		case !replace:
+			continue
+		case c.Less != nil:
+			ids = c.move(ids, changed, id, entity)
+		}
+		changed = changed.Set(id, entity)
+	}
+	if changed == state.Entities {
+		return state
+	}
+
+	return EntityState[ID, T]{
+		IDs:      c.merge(ids, changed, added),
+		Entities: changed,
+	}
+}
+
+// entityAt returns the entity of the id at the index
+func (c *Adapter[ID, T]) entityAt(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], index int) T {
+	entity, _ := entities.Get(ids.Get(index))
+	return entity
+}
+
+// indexOf returns the index of the id in the entities, by binary search if sorted
+func (c *Adapter[ID, T]) indexOf(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], id ID) int {
+	count := ids.Len()
+	if entity, ok := entities.Get(id); ok && c.Less != nil {
+		// the first of the ones equal to the entity
+		index := sort.Search(count, func(idx int) bool {
+			return !c.Less(c.entityAt(ids, entities, idx), entity)
+		})
+		for ; index < count && !c.Less(entity, c.entityAt(ids, entities, index)); index++ {
+			if ids.Get(index) == id {
+				return index
+			}
+		}
+		// Less is not consistent
+	}
+
+	found := -1
+	ids.Range(func(index int, value ID) bool {
+		if value == id {
+			found = index
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// insertAt returns the index to insert the entity at, after the equal ones
+func (c *Adapter[ID, T]) insertAt(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], entity T) int {
+	return sort.Search(ids.Len(), func(idx int) bool {
+		return c.Less(entity, c.entityAt(ids, entities, idx))
+	})
+}
+
+// move returns the ids with the id moved for the entity replacing old, the same ids if in order still
+func (c *Adapter[ID, T]) move(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], id ID, entity T) *immutable.Vector[ID] {
+	count := ids.Len()
+	index := c.indexOf(ids, entities, id)
+	if (index == 0 || !c.Less(entity, c.entityAt(ids, entities, index-1))) &&
+		(index == count-1 || !c.Less(c.entityAt(ids, entities, index+1), entity)) {
+		return ids
+	}
+
+	ids = ids.Delete(index)
+	return ids.Insert(c.insertAt(ids, entities, entity), id)
+}
+
+// merge returns the ids with the entities added, after the equal ones
+func (c *Adapter[ID, T]) merge(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], added []T) *immutable.Vector[ID] {
+	if len(added) == 0 {
+		return ids
+	}
+	if c.Less != nil {
+		sort.SliceStable(added, func(i, j int) bool {
+			return c.Less(added[i], added[j])
+		})
+	}
+
+	count := ids.Len()
+	switch {
+	case count == 0:
+		values := make([]ID, len(added))
+		for idx, entity := range added {
+			values[idx] = c.SelectID(entity)
+		}
+		return immutable.VectorOf(values...)
+	case c.Less == nil || !c.Less(added[0], c.entityAt(ids, entities, count-1)):
+		// appended
+		for _, entity := range added {
+			ids = ids.Append(c.SelectID(entity))
+		}
+		return ids
+	case len(added)*mergeRatio <= count:
+		// in order of added, after the ones added before
+		for _, entity := range added {
+			ids = ids.Insert(c.insertAt(ids, entities, entity), c.SelectID(entity))
+		}
+		return ids
+	}
+
+	// many added, merged in a pass
+	merged := make([]ID, 0, count+len(added))
+	next := 0
+	ids.Range(func(index int, id ID) bool {
+		existing, _ := entities.Get(id)
+		for ; next < len(added) && c.Less(added[next], existing); next++ {
+			merged = append(merged, c.SelectID(added[next]))
+		}
+		merged = append(merged, id)
+		return true
+	})
+	for _, entity := range added[next:] {
+		merged = append(merged, c.SelectID(entity))
+	}
+	return immutable.VectorOf(merged...)
+}
+
+// deleteIDs returns the ids without the removed ones in the entities
+func (c *Adapter[ID, T]) deleteIDs(ids *immutable.Vector[ID], entities *immutable.Map[ID, T], removed []ID) *immutable.Vector[ID] {
+	var indexes []int
+	if c.Less != nil || len(removed) == 1 {
+		for _, id := range removed {
+			indexes = append(indexes, c.indexOf(ids, entities, id))
+		}
+		sort.Ints(indexes)
+	} else {
+		// in a pass for all
+		removing := make(map[ID]bool, len(removed))
+		for _, id := range removed {
+			removing[id] = true
+		}
+		ids.Range(func(index int, id ID) bool {
+			if removing[id] {
+				indexes = append(indexes, index)
+			}
+			return len(indexes) < len(removed)
+		})
+	}
+
+	// from the last not to shift the others
+	for idx := len(indexes) - 1; idx >= 0; idx-- {
+		ids = ids.Delete(indexes[idx])
+	}
+	return ids
+}
+
+// replaceID returns the ids with the old one replaced, the new one removed if in already
+func (c *Adapter[ID, T]) replaceID(ids *immutable.Vector[ID], oldID ID, newID ID, replacing bool) *immutable.Vector[ID] {
+	oldAt, newAt := -1, -1
+	ids.Range(func(index int, id ID) bool {
+		switch id {
+		case oldID:
+			oldAt = index
+		case newID:
+			newAt = index
+		}
+		return oldAt < 0 || (replacing && newAt < 0)
+	})
+
+	ids = ids.Set(oldAt, newID)
+	if newAt >= 0 {
+		ids = ids.Delete(newAt)
+	}
+	return ids
+}
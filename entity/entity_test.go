@@ -0,0 +1,379 @@
+package entity
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+type user struct {
+	id   int
+	name string
+}
+
+func userID(entity user) int {
+	return entity.id
+}
+
+func byName(a, b user) bool {
+	return a.name < b.name
+}
+
+func assertEntities(t *testing.T, state EntityState[int, user], want []user) {
+	t.Helper()
+	got := state.All()
+	if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+		t.Errorf("All: want %v got %v", want, got)
+	}
+	if state.Entities.Len() != state.IDs.Len() {
+		t.Errorf("Entities: want %d got %d", state.IDs.Len(), state.Entities.Len())
+	}
+}
+
+func Test_Adapter(t *testing.T) {
+	alice, bob, carol := user{1, "alice"}, user{2, "bob"}, user{3, "carol"}
+
+	tests := []struct {
+		name   string
+		less   func(a, b user) bool
+		reduce func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user]
+		want   []user
+	}{
+		{
+			name: "add keeps the order added",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				return adapter.AddMany(adapter.AddOne(state, carol), alice, bob)
+			},
+			want: []user{carol, alice, bob},
+		},
+		{
+			name: "add ignores the id in",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				return adapter.AddMany(state, alice, user{1, "other"})
+			},
+			want: []user{alice},
+		},
+		{
+			name: "upsert replaces in place",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.AddMany(state, alice, bob)
+				return adapter.UpsertMany(state, user{1, "zed"}, carol)
+			},
+			want: []user{{1, "zed"}, bob, carol},
+		},
+		{
+			name: "sorted",
+			less: byName,
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.AddMany(state, carol, alice)
+				state = adapter.UpsertOne(state, bob)
+				return adapter.UpsertOne(state, user{1, "dave"})
+			},
+			want: []user{bob, carol, {1, "dave"}},
+		},
+		{
+			name: "update",
+			less: byName,
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.AddMany(state, alice, bob)
+				return adapter.UpdateOne(state, 2, func(entity user) user {
+					entity.name = "aaron"
+					return entity
+				})
+			},
+			want: []user{{2, "aaron"}, alice},
+		},
+		{
+			name: "update changes the id",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.AddMany(state, alice, bob, carol)
+				return adapter.UpdateOne(state, 1, func(entity user) user {
+					entity.id = 3
+					return entity
+				})
+			},
+			want: []user{{3, "alice"}, bob},
+		},
+		{
+			name: "update not in",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				return adapter.UpdateOne(adapter.AddOne(state, alice), 9, func(entity user) user {
+					panic("not called")
+				})
+			},
+			want: []user{alice},
+		},
+		{
+			name: "remove",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.AddMany(state, alice, bob, carol)
+				return adapter.RemoveMany(adapter.RemoveOne(state, 2), 3, 9)
+			},
+			want: []user{alice},
+		},
+		{
+			name: "set all and remove all",
+			reduce: func(adapter *Adapter[int, user], state EntityState[int, user]) EntityState[int, user] {
+				state = adapter.SetAll(adapter.AddOne(state, alice), bob, carol)
+				if !reflect.DeepEqual(state.All(), []user{bob, carol}) {
+					t.Errorf("SetAll: want [bob carol] got %v", state.All())
+				}
+				return adapter.RemoveAll(state)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewAdapter("users", userID)
+			adapter.Less = tt.less
+			assertEntities(t, tt.reduce(adapter, EntityState[int, user]{}), tt.want)
+		})
+	}
+}
+
+func Test_Adapter_Unchanged(t *testing.T) {
+	adapter := NewAdapter("users", userID)
+	state := adapter.Initial(user{1, "alice"})
+
+	if adapter.AddOne(state, user{1, "other"}) != state {
+		t.Errorf("AddOne: want the same state if the id is in")
+	}
+	if adapter.RemoveOne(state, 9) != state {
+		t.Errorf("RemoveOne: want the same state if the id is not in")
+	}
+	if adapter.RemoveAll(EntityState[int, user]{}) != (EntityState[int, user]{}) {
+		t.Errorf("RemoveAll: want the same state if empty")
+	}
+}
+
+func Test_Adapter_Sorted(t *testing.T) {
+	adapter := NewAdapter("users", userID)
+	adapter.Less = byName
+
+	t.Run("in order still", func(t *testing.T) {
+		state := adapter.Initial(user{1, "a"}, user{2, "c"}, user{3, "e"})
+		updated := adapter.UpsertOne(state, user{2, "d"})
+		if updated.IDs != state.IDs {
+			t.Errorf("UpsertOne: want the ids not moved")
+		}
+		appended := adapter.AddOne(state, user{4, "f"})
+		if appended.IDs.Len() != 4 || appended.IDs.Get(3) != 4 {
+			t.Errorf("AddOne: want appended got %v", appended.IDs.Slice())
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		randomUser := func() user {
+			return user{random.Intn(50), string(rune('a' + random.Intn(26)))}
+		}
+
+		var state EntityState[int, user]
+		for step := 0; step < 2000; step++ {
+			switch random.Intn(5) {
+			case 0:
+				state = adapter.AddMany(state, randomUser(), randomUser())
+			case 1:
+				state = adapter.UpsertMany(state, randomUser(), randomUser(), randomUser())
+			case 2:
+				state = adapter.UpdateOne(state, random.Intn(50), func(entity user) user {
+					entity.name = randomUser().name
+					return entity
+				})
+			case 3:
+				state = adapter.RemoveOne(state, random.Intn(50))
+			case 4:
+				state = adapter.UpdateOne(state, random.Intn(50), func(entity user) user {
+					entity.id = random.Intn(50)
+					return entity
+				})
+			}
+
+			all := state.All()
+			if len(all) != state.Entities.Len() {
+				t.Fatalf("step %d: want %d ids got %d", step, state.Entities.Len(), len(all))
+			}
+			seen := map[int]bool{}
+			for idx, entity := range all {
+				if seen[entity.id] || (idx > 0 && byName(entity, all[idx-1])) {
+					t.Fatalf("step %d: not sorted %v", step, all)
+				}
+				seen[entity.id] = true
+			}
+		}
+	})
+}
+
+func Test_Adapter_Random(t *testing.T) {
+	adapter := NewAdapter("users", userID)
+	random := rand.New(rand.NewSource(1))
+
+	// the ids in the order added
+	var state EntityState[int, user]
+	var want []int
+	indexOf := func(id int) int {
+		for idx, value := range want {
+			if value == id {
+				return idx
+			}
+		}
+		return -1
+	}
+	for step := 0; step < 2000; step++ {
+		id := random.Intn(50)
+		switch random.Intn(4) {
+		case 0:
+			state = adapter.UpsertOne(state, user{id, "x"})
+			if indexOf(id) < 0 {
+				want = append(want, id)
+			}
+		case 1:
+			other := random.Intn(50)
+			state = adapter.RemoveMany(state, id, other)
+			for _, removed := range []int{id, other} {
+				if idx := indexOf(removed); idx >= 0 {
+					want = append(want[:idx], want[idx+1:]...)
+				}
+			}
+		case 2:
+			newID := random.Intn(50)
+			state = adapter.UpdateOne(state, id, func(entity user) user {
+				entity.id = newID
+				return entity
+			})
+			if idx := indexOf(id); idx >= 0 && newID != id {
+				if other := indexOf(newID); other >= 0 {
+					want = append(want[:other], want[other+1:]...)
+				}
+				want[indexOf(id)] = newID
+			}
+		case 3:
+			other := random.Intn(50)
+			state = adapter.AddMany(state, user{id, "y"}, user{other, "z"})
+			for _, added := range []int{id, other} {
+				if indexOf(added) < 0 {
+					want = append(want, added)
+				}
+			}
+		}
+
+		if got := state.IDs.Slice(); len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Fatalf("step %d: want %v got %v", step, want, got)
+		}
+		if state.Entities.Len() != len(want) {
+			t.Fatalf("step %d: want %d entities got %d", step, len(want), state.Entities.Len())
+		}
+	}
+}
+
+func benchmarkState(adapter *Adapter[int, user], count int) EntityState[int, user] {
+	users := make([]user, count)
+	for idx := range users {
+		users[idx] = user{idx, fmt.Sprintf("%08d", idx)}
+	}
+	return adapter.Initial(users...)
+}
+
+func Benchmark_Adapter(b *testing.B) {
+	const count = 100000
+
+	b.Run("RemoveOne", func(b *testing.B) {
+		adapter := NewAdapter("users", userID)
+		state := benchmarkState(adapter, count)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for idx := 0; idx < b.N; idx++ {
+			adapter.RemoveOne(state, idx%count)
+		}
+	})
+
+	b.Run("RemoveOne sorted", func(b *testing.B) {
+		adapter := NewAdapter("users", userID)
+		adapter.Less = byName
+		state := benchmarkState(adapter, count)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for idx := 0; idx < b.N; idx++ {
+			adapter.RemoveOne(state, idx%count)
+		}
+	})
+
+	b.Run("UpsertOne sorted moved", func(b *testing.B) {
+		adapter := NewAdapter("users", userID)
+		adapter.Less = byName
+		state := benchmarkState(adapter, count)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for idx := 0; idx < b.N; idx++ {
+			adapter.UpsertOne(state, user{idx % count, fmt.Sprintf("%08d", count-idx%count)})
+		}
+	})
+
+	b.Run("AddOne sorted", func(b *testing.B) {
+		adapter := NewAdapter("users", userID)
+		adapter.Less = byName
+		state := benchmarkState(adapter, count)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for idx := 0; idx < b.N; idx++ {
+			adapter.AddOne(state, user{count + idx, fmt.Sprintf("%08d", idx%count)})
+		}
+	})
+}
+
+type usersState struct {
+	users  EntityState[int, user]
+	admins EntityState[int, user]
+}
+
+func (c usersState) StateInterface() {}
+
+func Test_Adapter_Reduce(t *testing.T) {
+	users := NewAdapter("users", userID)
+	admins := NewAdapter("admins", userID)
+	reducer := store.CombineSlices[usersState](
+		store.NewSlice("users", store.Lens[usersState, EntityState[int, user]]{
+			Get: func(state usersState) EntityState[int, user] {
+				return state.users
+			},
+			Set: func(state usersState, value EntityState[int, user]) usersState {
+				state.users = value
+				return state
+			},
+		}, users.Reduce),
+		store.NewSlice("admins", store.Lens[usersState, EntityState[int, user]]{
+			Get: func(state usersState) EntityState[int, user] {
+				return state.admins
+			},
+			Set: func(state usersState, value EntityState[int, user]) usersState {
+				state.admins = value
+				return state
+			},
+		}, admins.Reduce),
+	)
+
+	b := store.NewStore(usersState{}, reducer)
+	b.Dispatch(&AddManyAction[user]{Name: "users", Entities: []user{{1, "alice"}, {2, "bob"}, {3, "carol"}}})
+	b.Dispatch(&AddOneAction[user]{Name: "admins", Entity: user{1, "alice"}})
+	b.Dispatch(&UpsertOneAction[user]{Name: "users", Entity: user{4, "dave"}})
+	b.Dispatch(&UpsertManyAction[user]{Name: "users", Entities: []user{{4, "dan"}}})
+	b.Dispatch(&UpdateOneAction[int, user]{Name: "users", ID: 2, Update: func(entity user) user {
+		entity.name = "bobby"
+		return entity
+	}})
+	b.Dispatch(&RemoveOneAction[int]{Name: "users", ID: 1})
+	b.Dispatch(&RemoveManyAction[int]{Name: "users", IDs: []int{3}})
+	b.Dispatch(&SetAllAction[user]{Name: "admins", Entities: []user{{2, "bobby"}}})
+	b.Dispatch(&RemoveAllAction{Name: "nobody"})
+	b.Stop()
+	b.WaitForStore()
+
+	state := b.GetState()
+	assertEntities(t, state.users, []user{{2, "bobby"}, {4, "dan"}})
+	assertEntities(t, state.admins, []user{{2, "bobby"}})
+}
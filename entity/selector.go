@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"github.com/rookiecj/go-store/store"
+)
+
+// Selectors selects EntityState in the state S.
+type Selectors[S store.State, ID comparable, T any] struct {
+	// IDs selects the ids in order
+	IDs store.Selector[S, []ID]
+	// All selects the entities in the order of the ids
+	All store.Selector[S, []T]
+	// Total selects the number of the entities
+	Total store.Selector[S, int]
+
+	selectState store.Selector[S, EntityState[ID, T]]
+}
+
+// NewSelectors creates the selectors of EntityState selected by selectState.
+// IDs and All are memoized, computed again only when EntityState changes.
+func NewSelectors[S store.State, ID comparable, T any](selectState store.Selector[S, EntityState[ID, T]]) *Selectors[S, ID, T] {
+	return &Selectors[S, ID, T]{
+		IDs: store.CreateSelector1(selectState, func(state EntityState[ID, T]) []ID {
+			return state.IDs.Slice()
+		}),
+		All: store.CreateSelector1(selectState, func(state EntityState[ID, T]) []T {
+			return state.All()
+		}),
+		Total: func(state S) int {
+			return selectState(state).Len()
+		},
+		selectState: selectState,
+	}
+}
+
+// ByID returns the entity of the id in the state.
+func (c *Selectors[S, ID, T]) ByID(state S, id ID) (T, bool) {
+	return c.selectState(state).Get(id)
+}
+
+// SelectByID returns a selector of the entity of the id, the zero value if not in.
+func (c *Selectors[S, ID, T]) SelectByID(id ID) store.Selector[S, T] {
+	return func(state S) T {
+		entity, _ := c.ByID(state, id)
+		return entity
+	}
+}
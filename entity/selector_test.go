@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Selectors(t *testing.T) {
+	adapter := NewAdapter("users", userID)
+	adapter.Less = byName
+
+	selectors := NewSelectors(func(state usersState) EntityState[int, user] {
+		return state.users
+	})
+
+	state := usersState{users: adapter.Initial(user{2, "bob"}, user{1, "alice"})}
+	if got := selectors.IDs(state); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("IDs: want [1 2] got %v", got)
+	}
+	if got := selectors.Total(state); got != 2 {
+		t.Errorf("Total: want 2 got %d", got)
+	}
+	if got, ok := selectors.ByID(state, 2); !ok || got.name != "bob" {
+		t.Errorf("ByID: want bob got %v %v", got, ok)
+	}
+	if got := selectors.SelectByID(9)(state); got != (user{}) {
+		t.Errorf("SelectByID: want zero got %v", got)
+	}
+
+	first := selectors.All(state)
+	// unchanged
+	state.users = adapter.AddOne(state.users, user{1, "other"})
+	second := selectors.All(state)
+	if &first[0] != &second[0] {
+		t.Errorf("All: want memoized")
+	}
+
+	state.users = adapter.RemoveOne(state.users, 1)
+	if got := selectors.All(state); !reflect.DeepEqual(got, []user{{2, "bob"}}) {
+		t.Errorf("All: want [bob] got %v", got)
+	}
+}
@@ -0,0 +1,251 @@
+package history
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/rookiecj/go-store/sched"
+	"github.com/rookiecj/go-store/store"
+)
+
+// History holds the present state with the past and the future states for undo and redo.
+// it is a value class, it is not modified but replaced by the reducer.
+type History[S store.State] struct {
+	// Present is the current state
+	Present S
+	// Age is the age of the store when Present is made
+	Age int64
+
+	past   []entry[S]
+	future []entry[S]
+	// group of the last undoable action
+	group any
+}
+
+type entry[S store.State] struct {
+	state S
+	age   int64
+}
+
+func (c History[S]) StateInterface() {}
+
+// CanUndo reports whether there is a past state.
+func (c History[S]) CanUndo() bool {
+	return len(c.past) > 0
+}
+
+// CanRedo reports whether there is a future state.
+func (c History[S]) CanRedo() bool {
+	return len(c.future) > 0
+}
+
+// Ages returns the ages of the past, the present and the future states in order.
+// an age can be used with JumpToAction.
+func (c History[S]) Ages() (ages []int64) {
+	for _, past := range c.past {
+		ages = append(ages, past.age)
+	}
+	ages = append(ages, c.Age)
+	for idx := len(c.future) - 1; idx >= 0; idx-- {
+		ages = append(ages, c.future[idx].age)
+	}
+	return
+}
+
+// UndoAction moves to the previous state.
+type UndoAction struct{}
+
+// RedoAction moves to the next state.
+type RedoAction struct{}
+
+// JumpToAction moves to the state made at the age.
+type JumpToAction struct {
+	Age int64
+}
+
+// Options configures how the history is recorded.
+type Options[S store.State] struct {
+	// Limit is the maximum number of the past states, no limit if 0
+	Limit int
+
+	// Filter reports whether an action is undoable, all actions are undoable if nil.
+	// the state changed by an action not undoable replaces the present.
+	Filter func(action store.Action) bool
+
+	// GroupBy returns the group of an action, consecutive actions in the same group are one undo step.
+	// nil means no group, and so does a group not comparable, a slice or a map e.g.
+	GroupBy func(action store.Action) any
+
+	// Equal reports whether two states are the same, reflect.DeepEqual is used if nil.
+	// an action which does not change the state is not recorded.
+	Equal func(a, b S) bool
+}
+
+// Undoable is a store with the history of the state.
+type Undoable[S store.State] struct {
+	store   atomic.Pointer[store.Store[History[S]]]
+	options Options[S]
+}
+
+// NewStore creates an undoable store with the reducer and the initial state.
+func NewStore[S store.State](initialState S, reducer store.Reducer[S], options Options[S]) *Undoable[S] {
+	return NewStoreOn(sched.NewMainScheduler(), initialState, reducer, options)
+}
+
+// NewStoreOn creates an undoable store on the scheduler.
+func NewStoreOn[S store.State](scheduler sched.Scheduler, initialState S, reducer store.Reducer[S], options Options[S]) *Undoable[S] {
+	undoable := &Undoable[S]{
+		options: options,
+	}
+	stateStore := store.NewStoreOn(scheduler, History[S]{Present: initialState}, Reducer(reducer, options, undoable.nextAge))
+	undoable.store.Store(&stateStore)
+	return undoable
+}
+
+// Store returns the store of the history.
+func (c *Undoable[S]) Store() store.Store[History[S]] {
+	return *c.store.Load()
+}
+
+// GetState returns the present state.
+func (c *Undoable[S]) GetState() S {
+	return c.Store().GetState().Present
+}
+
+// Undo moves to the previous state.
+func (c *Undoable[S]) Undo() {
+	c.Store().Dispatch(&UndoAction{})
+}
+
+// Redo moves to the next state.
+func (c *Undoable[S]) Redo() {
+	c.Store().Dispatch(&RedoAction{})
+}
+
+// JumpTo moves to the state made at the age.
+func (c *Undoable[S]) JumpTo(age int64) {
+	c.Store().Dispatch(&JumpToAction{Age: age})
+}
+
+// nextAge returns the age of the store after reducing the current action
+func (c *Undoable[S]) nextAge() int64 {
+	stateStore := c.store.Load()
+	if stateStore == nil {
+		// InitAction is reduced first while creating the store
+		return 1
+	}
+	_, age := (*stateStore).Snapshot()
+	return age + 1
+}
+
+// Reducer wraps a reducer to record the history of the state.
+// age returns the age of the store for the state being reduced, the same for the actions of a BatchAction made one step.
+func Reducer[S store.State](reducer store.Reducer[S], options Options[S], age func() int64) store.Reducer[History[S]] {
+	equal := options.Equal
+	if equal == nil {
+		equal = func(a, b S) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+
+	return func(history History[S], action store.Action) (History[S], error) {
+		switch reified := action.(type) {
+		case *UndoAction:
+			return history.undo(), nil
+		case *RedoAction:
+			return history.redo(), nil
+		case *JumpToAction:
+			return history.jumpTo(reified.Age), nil
+		case *store.InitAction:
+			present, err := reducer(history.Present, action)
+			history.Present = present
+			history.Age = age()
+			return history, err
+		}
+
+		present, err := reducer(history.Present, action)
+		if equal(present, history.Present) {
+			return history, err
+		}
+
+		if options.Filter != nil && !options.Filter(action) {
+			history.Present = present
+			history.Age = age()
+			return history, err
+		}
+
+		var group any
+		if options.GroupBy != nil {
+			group = options.GroupBy(action)
+			if group != nil && !reflect.TypeOf(group).Comparable() {
+				// compared with != below
+				group = nil
+			}
+		}
+		now := age()
+		// the actions of a BatchAction are reduced at the same age, one step
+		batched := now == history.Age
+		if !batched && (group == nil || group != history.group || !history.CanUndo()) {
+			history.past = appendEntry(history.past, entry[S]{history.Present, history.Age})
+			if options.Limit > 0 && len(history.past) > options.Limit {
+				history.past = history.past[len(history.past)-options.Limit:]
+			}
+		}
+		history.Present = present
+		history.Age = now
+		history.future = nil
+		history.group = group
+		return history, err
+	}
+}
+
+func (c History[S]) undo() History[S] {
+	if !c.CanUndo() {
+		return c
+	}
+	last := len(c.past) - 1
+	c.future = appendEntry(c.future, entry[S]{c.Present, c.Age})
+	c.Present, c.Age = c.past[last].state, c.past[last].age
+	c.past = c.past[:last:last]
+	c.group = nil
+	return c
+}
+
+func (c History[S]) redo() History[S] {
+	if !c.CanRedo() {
+		return c
+	}
+	last := len(c.future) - 1
+	c.past = appendEntry(c.past, entry[S]{c.Present, c.Age})
+	c.Present, c.Age = c.future[last].state, c.future[last].age
+	c.future = c.future[:last:last]
+	c.group = nil
+	return c
+}
+
+func (c History[S]) jumpTo(age int64) History[S] {
+	for idx := range c.past {
+		if c.past[idx].age == age {
+			for c.Age != age {
+				c = c.undo()
+			}
+			return c
+		}
+	}
+	for idx := range c.future {
+		if c.future[idx].age == age {
+			for c.Age != age {
+				c = c.redo()
+			}
+			return c
+		}
+	}
+	return c
+}
+
+// appendEntry appends to a copy not to share with the previous history
+func appendEntry[S store.State](entries []entry[S], e entry[S]) []entry[S] {
+	appended := make([]entry[S], len(entries), len(entries)+1)
+	copy(appended, entries)
+	return append(appended, e)
+}
@@ -0,0 +1,278 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+type docState struct {
+	text   string
+	cursor int
+}
+
+func (c docState) StateInterface() {}
+
+type typeAction struct {
+	text string
+	word int // group
+}
+
+type moveAction struct {
+	cursor int
+}
+
+func docReducer(state docState, action store.Action) (docState, error) {
+	switch reified := action.(type) {
+	case *typeAction:
+		return docState{text: state.text + reified.text, cursor: state.cursor}, nil
+	case *moveAction:
+		return docState{text: state.text, cursor: reified.cursor}, nil
+	}
+	return state, nil
+}
+
+func Test_Undoable(t *testing.T) {
+
+	type args struct {
+		options Options[docState]
+		actions []store.Action
+	}
+	type testCaseUndoable struct {
+		name       string
+		args       args
+		want       docState
+		wantUndo   bool
+		wantRedo   bool
+		wantLength int // length of the history
+	}
+
+	tests := []testCaseUndoable{
+		{
+			name: "undo",
+			args: args{
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&typeAction{text: "b"},
+					&UndoAction{},
+				},
+			},
+			want:       docState{text: "a"},
+			wantUndo:   true,
+			wantRedo:   true,
+			wantLength: 3,
+		},
+		{
+			name: "undo all and redo",
+			args: args{
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&typeAction{text: "b"},
+					&UndoAction{},
+					&UndoAction{},
+					&UndoAction{},
+					&RedoAction{},
+				},
+			},
+			want:       docState{text: "a"},
+			wantUndo:   true,
+			wantRedo:   true,
+			wantLength: 3,
+		},
+		{
+			name: "new action clears future",
+			args: args{
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&typeAction{text: "b"},
+					&UndoAction{},
+					&typeAction{text: "c"},
+				},
+			},
+			want:       docState{text: "ac"},
+			wantUndo:   true,
+			wantRedo:   false,
+			wantLength: 3,
+		},
+		{
+			name: "limit",
+			args: args{
+				options: Options[docState]{
+					Limit: 1,
+				},
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&typeAction{text: "b"},
+					&typeAction{text: "c"},
+					&UndoAction{},
+					&UndoAction{},
+				},
+			},
+			want:       docState{text: "ab"},
+			wantUndo:   false,
+			wantRedo:   true,
+			wantLength: 2,
+		},
+		{
+			name: "group",
+			args: args{
+				options: Options[docState]{
+					GroupBy: func(action store.Action) any {
+						if reified, ok := action.(*typeAction); ok {
+							return reified.word
+						}
+						return nil
+					},
+				},
+				actions: []store.Action{
+					&typeAction{text: "a", word: 1},
+					&typeAction{text: "b", word: 1},
+					&typeAction{text: " ", word: 2},
+					&typeAction{text: "c", word: 2},
+					&UndoAction{},
+				},
+			},
+			want:       docState{text: "ab"},
+			wantUndo:   true,
+			wantRedo:   true,
+			wantLength: 3,
+		},
+		{
+			name: "group not comparable",
+			args: args{
+				options: Options[docState]{
+					GroupBy: func(action store.Action) any {
+						return []string{"typing"}
+					},
+				},
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&typeAction{text: "b"},
+					&UndoAction{},
+				},
+			},
+			want:       docState{text: "a"},
+			wantUndo:   true,
+			wantRedo:   true,
+			wantLength: 3,
+		},
+		{
+			name: "filter",
+			args: args{
+				options: Options[docState]{
+					Filter: func(action store.Action) bool {
+						_, ok := action.(*typeAction)
+						return ok
+					},
+				},
+				actions: []store.Action{
+					&typeAction{text: "a"},
+					&moveAction{cursor: 1},
+					&typeAction{text: "b"},
+					&moveAction{cursor: 2},
+					&UndoAction{},
+				},
+			},
+			want:       docState{text: "a", cursor: 1},
+			wantUndo:   true,
+			wantRedo:   true,
+			wantLength: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			undoable := NewStore(docState{}, docReducer, tt.args.options)
+
+			for _, action := range tt.args.actions {
+				undoable.Store().Dispatch(action)
+			}
+
+			undoable.Store().Stop()
+			undoable.Store().WaitForStore()
+
+			got := undoable.Store().GetState()
+			if !reflect.DeepEqual(tt.want, got.Present) {
+				t.Errorf("Undoable: want %v got %v", tt.want, got.Present)
+			}
+			if tt.wantUndo != got.CanUndo() || tt.wantRedo != got.CanRedo() {
+				t.Errorf("Undoable: CanUndo/CanRedo want %v/%v got %v/%v", tt.wantUndo, tt.wantRedo, got.CanUndo(), got.CanRedo())
+			}
+			if tt.wantLength != len(got.Ages()) {
+				t.Errorf("Undoable: history want %d got %v", tt.wantLength, got.Ages())
+			}
+		})
+	}
+}
+
+func Test_Undoable_JumpTo(t *testing.T) {
+
+	t.Run("jump to the age of the store", func(t *testing.T) {
+		undoable := NewStore(docState{}, docReducer, Options[docState]{})
+
+		undoable.Store().Dispatch(&typeAction{text: "a"})
+		undoable.Store().Dispatch(&typeAction{text: "b"})
+		undoable.Store().Dispatch(&typeAction{text: "c"})
+		undoable.JumpTo(3)
+
+		undoable.Store().Stop()
+		undoable.Store().WaitForStore()
+
+		// InitAction is 1
+		got, age := undoable.Store().Snapshot()
+		if want := []int64{1, 2, 3, 4}; !reflect.DeepEqual(want, got.Ages()) {
+			t.Errorf("JumpTo: ages want %v got %v", want, got.Ages())
+		}
+		if got.Present.text != "ab" || got.Age != 3 || age != 5 {
+			t.Errorf("JumpTo: got %v at %d, store age %d", got.Present, got.Age, age)
+		}
+	})
+
+	t.Run("a batch is one step", func(t *testing.T) {
+		undoable := NewStore(docState{}, docReducer, Options[docState]{})
+
+		undoable.Store().DispatchBatch(&typeAction{text: "a"}, &typeAction{text: "b"})
+		undoable.Store().Dispatch(&typeAction{text: "c"})
+		undoable.Undo()
+		undoable.Store().Stop()
+		undoable.Store().WaitForStore()
+
+		got := undoable.Store().GetState()
+		if want := []int64{1, 2, 3}; !reflect.DeepEqual(want, got.Ages()) {
+			t.Errorf("DispatchBatch: ages want %v got %v", want, got.Ages())
+		}
+		if got.Present.text != "ab" {
+			t.Errorf("Undo: want ab got %v", got.Present)
+		}
+		if undone := got.undo(); undone.Present.text != "" {
+			t.Errorf("Undo: want the state before the batch got %v", undone.Present)
+		}
+	})
+
+	t.Run("CanUndo observed by subscribers", func(t *testing.T) {
+		undoable := NewStore(docState{}, docReducer, Options[docState]{})
+
+		var canUndo []bool
+		store.SubscribeSelect(undoable.Store(), History[docState].CanUndo, nil, func(newValue bool, oldValue bool, action store.Action) {
+			canUndo = append(canUndo, newValue)
+		})
+
+		undoable.Store().Dispatch(&typeAction{text: "a"})
+		undoable.Store().Dispatch(&typeAction{text: "b"})
+		undoable.Undo()
+		undoable.Undo()
+		undoable.Redo()
+
+		undoable.Store().Stop()
+		undoable.Store().WaitForStore()
+
+		if want := []bool{false, true, false, true}; !reflect.DeepEqual(want, canUndo) {
+			t.Errorf("CanUndo: want %v got %v", want, canUndo)
+		}
+		if got := undoable.GetState(); got.text != "a" {
+			t.Errorf("Redo: got %v", got)
+		}
+	})
+}
@@ -0,0 +1,364 @@
+package immutable
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// seed of the default hasher, in the process
+var seed = maphash.MakeSeed()
+
+// Map is a persistent hash array mapped trie, the changes return a new Map sharing the structure with the old one.
+// the nil Map is empty with the default hasher, a Map can be used as a field of a State and compared by the pointer.
+type Map[K comparable, V any] struct {
+	size   int
+	root   *mapNode[K, V]
+	hasher func(K) uint64
+}
+
+type mapNode[K comparable, V any] struct {
+	bitmap uint32
+	slots  []mapSlot[K, V]
+}
+
+// mapSlot is either a node or the leaves, more than one leaf if the hashes are the same
+type mapSlot[K comparable, V any] struct {
+	node   *mapNode[K, V]
+	leaves []mapLeaf[K, V]
+}
+
+type mapLeaf[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+}
+
+// NewMap creates an empty Map with the hasher, the default hasher if nil.
+func NewMap[K comparable, V any](hasher func(K) uint64) *Map[K, V] {
+	if hasher == nil {
+		hasher = DefaultHasher[K]()
+	}
+	return &Map[K, V]{hasher: hasher}
+}
+
+// DefaultHasher returns the hasher consistent with ==.
+// the pointers and the channels are hashed by the address, the interfaces by the dynamic values.
+func DefaultHasher[K comparable]() func(K) uint64 {
+	return func(key K) uint64 {
+		switch reified := any(key).(type) {
+		case string:
+			return maphash.String(seed, reified)
+		case int:
+			return mix(uint64(reified))
+		case int64:
+			return mix(uint64(reified))
+		case uint64:
+			return mix(reified)
+		}
+		var hash maphash.Hash
+		hash.SetSeed(seed)
+		writeValue(&hash, reflect.ValueOf(key))
+		return hash.Sum64()
+	}
+}
+
+// writeValue writes the value to the hash, the values equal by == are written the same
+func writeValue(hash *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		// nil interface
+		writeUint64(hash, 0)
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint64(hash, 1)
+		} else {
+			writeUint64(hash, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(hash, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(hash, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint64(hash, floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		writeUint64(hash, floatBits(real(v.Complex())))
+		writeUint64(hash, floatBits(imag(v.Complex())))
+	case reflect.String:
+		hash.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(hash, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint64(hash, 0)
+			return
+		}
+		hash.WriteString(v.Elem().Type().String())
+		writeValue(hash, v.Elem())
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			writeValue(hash, v.Field(idx))
+		}
+	case reflect.Array:
+		for idx := 0; idx < v.Len(); idx++ {
+			writeValue(hash, v.Index(idx))
+		}
+	default:
+		panic("immutable: key of " + v.Type().String() + " is not comparable")
+	}
+}
+
+func writeUint64(hash *maphash.Hash, x uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	hash.Write(buf[:])
+}
+
+// floatBits returns the same bits for 0 and -0 which are equal
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix is the finalizer of splitmix64
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Len returns the number of the entries.
+func (c *Map[K, V]) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.size
+}
+
+// Get returns the value of the key.
+func (c *Map[K, V]) Get(key K) (value V, ok bool) {
+	if c == nil || c.root == nil {
+		return value, false
+	}
+	hash := c.hasher(key)
+	node := c.root
+	for shift := uint(0); ; shift += levelBits {
+		bit := uint32(1) << ((hash >> shift) & mask)
+		if node.bitmap&bit == 0 {
+			return value, false
+		}
+		slot := node.slots[node.position(bit)]
+		if slot.node != nil {
+			node = slot.node
+			continue
+		}
+		for _, leaf := range slot.leaves {
+			if leaf.hash == hash && leaf.key == key {
+				return leaf.value, true
+			}
+		}
+		return value, false
+	}
+}
+
+// Has returns true if the key is in the Map.
+func (c *Map[K, V]) Has(key K) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Set returns a Map with the value of the key.
+func (c *Map[K, V]) Set(key K, value V) *Map[K, V] {
+	if c == nil {
+		c = NewMap[K, V](nil)
+	}
+	leaf := mapLeaf[K, V]{
+		hash:  c.hasher(key),
+		key:   key,
+		value: value,
+	}
+	root, added := c.root.set(0, leaf)
+	m := *c
+	m.root = root
+	if added {
+		m.size++
+	}
+	return &m
+}
+
+// Delete returns a Map without the key, the same Map if the key is not in.
+func (c *Map[K, V]) Delete(key K) *Map[K, V] {
+	if c == nil || c.root == nil {
+		return c
+	}
+	root, removed := c.root.delete(0, c.hasher(key), key)
+	if !removed {
+		return c
+	}
+	m := *c
+	m.root = root
+	m.size--
+	return &m
+}
+
+// Range calls fn with the entries in no particular order until fn returns false.
+func (c *Map[K, V]) Range(fn func(key K, value V) bool) {
+	if c == nil || c.root == nil {
+		return
+	}
+	c.root.walk(fn)
+}
+
+// Keys returns the keys in no particular order.
+func (c *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	c.Range(func(key K, value V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (c *mapNode[K, V]) position(bit uint32) int {
+	return bits.OnesCount32(c.bitmap & (bit - 1))
+}
+
+// set returns a copy of the node with the leaf, the node can be nil
+func (c *mapNode[K, V]) set(shift uint, leaf mapLeaf[K, V]) (*mapNode[K, V], bool) {
+	if c == nil {
+		c = &mapNode[K, V]{}
+	}
+	bit := uint32(1) << ((leaf.hash >> shift) & mask)
+	pos := c.position(bit)
+	if c.bitmap&bit == 0 {
+		return c.withSlot(bit, pos, mapSlot[K, V]{leaves: []mapLeaf[K, V]{leaf}}), true
+	}
+
+	slot := c.slots[pos]
+	if slot.node != nil {
+		child, added := slot.node.set(shift+levelBits, leaf)
+		return c.replaceSlot(pos, mapSlot[K, V]{node: child}), added
+	}
+	if slot.leaves[0].hash != leaf.hash {
+		// split into a node, the hashes differ at a level below
+		child := newMapNode(shift+levelBits, slot.leaves, leaf)
+		return c.replaceSlot(pos, mapSlot[K, V]{node: child}), true
+	}
+
+	// the same hash
+	leaves := make([]mapLeaf[K, V], len(slot.leaves), len(slot.leaves)+1)
+	copy(leaves, slot.leaves)
+	for idx := range leaves {
+		if leaves[idx].key == leaf.key {
+			leaves[idx] = leaf
+			return c.replaceSlot(pos, mapSlot[K, V]{leaves: leaves}), false
+		}
+	}
+	return c.replaceSlot(pos, mapSlot[K, V]{leaves: append(leaves, leaf)}), true
+}
+
+// newMapNode creates a node of the leaves of the same hash and the leaf of another
+func newMapNode[K comparable, V any](shift uint, leaves []mapLeaf[K, V], leaf mapLeaf[K, V]) *mapNode[K, V] {
+	idx := (leaves[0].hash >> shift) & mask
+	other := (leaf.hash >> shift) & mask
+	if idx == other {
+		return &mapNode[K, V]{
+			bitmap: 1 << idx,
+			slots:  []mapSlot[K, V]{{node: newMapNode(shift+levelBits, leaves, leaf)}},
+		}
+	}
+
+	slots := []mapSlot[K, V]{{leaves: leaves}, {leaves: []mapLeaf[K, V]{leaf}}}
+	if other < idx {
+		slots[0], slots[1] = slots[1], slots[0]
+	}
+	return &mapNode[K, V]{
+		bitmap: 1<<idx | 1<<other,
+		slots:  slots,
+	}
+}
+
+// delete returns a copy of the node without the key, nil if empty
+func (c *mapNode[K, V]) delete(shift uint, hash uint64, key K) (*mapNode[K, V], bool) {
+	bit := uint32(1) << ((hash >> shift) & mask)
+	if c.bitmap&bit == 0 {
+		return c, false
+	}
+	pos := c.position(bit)
+	slot := c.slots[pos]
+
+	if slot.node != nil {
+		child, removed := slot.node.delete(shift+levelBits, hash, key)
+		if !removed {
+			return c, false
+		}
+		switch {
+		case child == nil:
+			return c.withoutSlot(bit, pos), true
+		case len(child.slots) == 1 && child.slots[0].node == nil:
+			// pull up the leaves
+			return c.replaceSlot(pos, child.slots[0]), true
+		}
+		return c.replaceSlot(pos, mapSlot[K, V]{node: child}), true
+	}
+
+	for idx, leaf := range slot.leaves {
+		if leaf.hash != hash || leaf.key != key {
+			continue
+		}
+		if len(slot.leaves) == 1 {
+			return c.withoutSlot(bit, pos), true
+		}
+		leaves := make([]mapLeaf[K, V], 0, len(slot.leaves)-1)
+		leaves = append(append(leaves, slot.leaves[:idx]...), slot.leaves[idx+1:]...)
+		return c.replaceSlot(pos, mapSlot[K, V]{leaves: leaves}), true
+	}
+	return c, false
+}
+
+func (c *mapNode[K, V]) withSlot(bit uint32, pos int, slot mapSlot[K, V]) *mapNode[K, V] {
+	slots := make([]mapSlot[K, V], 0, len(c.slots)+1)
+	slots = append(slots, c.slots[:pos]...)
+	slots = append(slots, slot)
+	slots = append(slots, c.slots[pos:]...)
+	return &mapNode[K, V]{bitmap: c.bitmap | bit, slots: slots}
+}
+
+func (c *mapNode[K, V]) withoutSlot(bit uint32, pos int) *mapNode[K, V] {
+	if len(c.slots) == 1 {
+		return nil
+	}
+	slots := make([]mapSlot[K, V], 0, len(c.slots)-1)
+	slots = append(append(slots, c.slots[:pos]...), c.slots[pos+1:]...)
+	return &mapNode[K, V]{bitmap: c.bitmap &^ bit, slots: slots}
+}
+
+func (c *mapNode[K, V]) replaceSlot(pos int, slot mapSlot[K, V]) *mapNode[K, V] {
+	slots := append([]mapSlot[K, V](nil), c.slots...)
+	slots[pos] = slot
+	return &mapNode[K, V]{bitmap: c.bitmap, slots: slots}
+}
+
+func (c *mapNode[K, V]) walk(fn func(key K, value V) bool) bool {
+	for _, slot := range c.slots {
+		if slot.node != nil {
+			if !slot.node.walk(fn) {
+				return false
+			}
+			continue
+		}
+		for _, leaf := range slot.leaves {
+			if !fn(leaf.key, leaf.value) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package immutable
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type pointKey struct {
+	x, y int
+}
+
+func Test_Map(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher func(int) uint64
+	}{
+		{name: "default hasher"},
+		{
+			name: "collisions",
+			hasher: func(key int) uint64 {
+				return uint64(key % 7)
+			},
+		},
+		{
+			name: "differ in the last bits",
+			hasher: func(key int) uint64 {
+				return uint64(key%5) << 60
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(1))
+			m := NewMap[int, int](tt.hasher)
+			want := map[int]int{}
+			for step := 0; step < 5000; step++ {
+				key := random.Intn(500)
+				if random.Intn(3) == 0 {
+					m = m.Delete(key)
+					delete(want, key)
+				} else {
+					m = m.Set(key, step)
+					want[key] = step
+				}
+			}
+
+			if m.Len() != len(want) {
+				t.Fatalf("Len: want %d got %d", len(want), m.Len())
+			}
+			for key := 0; key < 500; key++ {
+				value, ok := m.Get(key)
+				wantValue, wantOk := want[key]
+				if ok != wantOk || value != wantValue {
+					t.Fatalf("Get(%d): want %d %v got %d %v", key, wantValue, wantOk, value, ok)
+				}
+			}
+			count := 0
+			m.Range(func(key int, value int) bool {
+				count++
+				if want[key] != value {
+					t.Errorf("Range: %d want %d got %d", key, want[key], value)
+				}
+				return true
+			})
+			if count != len(want) {
+				t.Errorf("Range: want %d entries got %d", len(want), count)
+			}
+
+			// deletes all
+			for key := range want {
+				m = m.Delete(key)
+			}
+			if m.Len() != 0 || m.root != nil {
+				t.Errorf("Delete: want empty got %d", m.Len())
+			}
+		})
+	}
+}
+
+func Test_Map_Persistent(t *testing.T) {
+	var empty *Map[string, int]
+	if empty.Len() != 0 || empty.Has("a") || empty.Delete("a") != nil {
+		t.Errorf("nil Map: want empty")
+	}
+
+	first := empty.Set("a", 1).Set("b", 2)
+	second := first.Set("a", 10).Delete("b").Set("c", 3)
+
+	if value, _ := first.Get("a"); value != 1 || !first.Has("b") || first.Has("c") {
+		t.Errorf("first: changed by second")
+	}
+	if value, _ := second.Get("a"); value != 10 || second.Has("b") || !second.Has("c") {
+		t.Errorf("second: want a=10 c=3")
+	}
+	if second.Delete("x") != second {
+		t.Errorf("Delete: want the same Map if not in")
+	}
+
+	keys := second.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Errorf("Keys: want [a c] got %v", keys)
+	}
+}
+
+func Test_DefaultHasher(t *testing.T) {
+	points := NewMap[pointKey, string](nil).
+		Set(pointKey{1, 2}, "a").
+		Set(pointKey{2, 1}, "b")
+	if value, _ := points.Get(pointKey{1, 2}); value != "a" {
+		t.Errorf("Get: want a got %s", value)
+	}
+	if value, _ := points.Get(pointKey{2, 1}); value != "b" {
+		t.Errorf("Get: want b got %s", value)
+	}
+
+	hasher := DefaultHasher[int64]()
+	if hasher(1) != hasher(1) || hasher(1) == hasher(2) {
+		t.Errorf("DefaultHasher: want stable and distinct")
+	}
+
+	// by the address, not the pointee
+	name := "a"
+	pointers := NewMap[*string, int](nil).Set(&name, 1)
+	name = "b"
+	if !pointers.Has(&name) || pointers.Has(new(string)) {
+		t.Errorf("Get: want the pointer by the address")
+	}
+
+	zero := 0.0
+	floats := NewMap[float64, string](nil).Set(zero, "zero")
+	if value, _ := floats.Get(-zero); value != "zero" {
+		t.Errorf("Get(-0): want zero got %q", value)
+	}
+
+	type node struct {
+		next  *node
+		value float64
+	}
+	next := &node{}
+	nodes := NewMap[node, int](nil).Set(node{next, zero}, 1)
+	next.value = 1
+	if !nodes.Has(node{next, -zero}) || nodes.Has(node{&node{}, zero}) {
+		t.Errorf("Get: want the struct equal by ==")
+	}
+}
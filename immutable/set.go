@@ -0,0 +1,75 @@
+package immutable
+
+// Set is a persistent set on Map, the changes return a new Set sharing the structure with the old one.
+// the nil Set is empty with the default hasher, a Set can be used as a field of a State and compared by the pointer.
+type Set[T comparable] struct {
+	m *Map[T, struct{}]
+}
+
+// NewSet creates an empty Set with the hasher, the default hasher if nil.
+func NewSet[T comparable](hasher func(T) uint64) *Set[T] {
+	return &Set[T]{m: NewMap[T, struct{}](hasher)}
+}
+
+// SetOf creates a Set of the values with the default hasher.
+func SetOf[T comparable](values ...T) *Set[T] {
+	var set *Set[T]
+	for _, value := range values {
+		set = set.Add(value)
+	}
+	return set
+}
+
+// Len returns the number of the values.
+func (c *Set[T]) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.m.Len()
+}
+
+// Has returns true if the value is in the Set.
+func (c *Set[T]) Has(value T) bool {
+	if c == nil {
+		return false
+	}
+	return c.m.Has(value)
+}
+
+// Add returns a Set with the value, the same Set if the value is in.
+func (c *Set[T]) Add(value T) *Set[T] {
+	if c.Has(value) {
+		return c
+	}
+	var m *Map[T, struct{}]
+	if c != nil {
+		m = c.m
+	}
+	return &Set[T]{m: m.Set(value, struct{}{})}
+}
+
+// Remove returns a Set without the value, the same Set if the value is not in.
+func (c *Set[T]) Remove(value T) *Set[T] {
+	if !c.Has(value) {
+		return c
+	}
+	return &Set[T]{m: c.m.Delete(value)}
+}
+
+// Range calls fn with the values in no particular order until fn returns false.
+func (c *Set[T]) Range(fn func(value T) bool) {
+	if c == nil {
+		return
+	}
+	c.m.Range(func(key T, value struct{}) bool {
+		return fn(key)
+	})
+}
+
+// Slice returns the values in no particular order.
+func (c *Set[T]) Slice() []T {
+	if c == nil {
+		return nil
+	}
+	return c.m.Keys()
+}
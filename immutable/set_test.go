@@ -0,0 +1,36 @@
+package immutable
+
+import (
+	"sort"
+	"testing"
+)
+
+func Test_Set(t *testing.T) {
+	var empty *Set[string]
+	if empty.Len() != 0 || empty.Has("a") || empty.Remove("a") != nil || empty.Slice() != nil {
+		t.Errorf("nil Set: want empty")
+	}
+
+	set := SetOf("a", "b", "a")
+	if set.Len() != 2 || !set.Has("a") || !set.Has("b") {
+		t.Errorf("SetOf: want [a b] got %v", set.Slice())
+	}
+	if set.Add("a") != set || set.Remove("x") != set {
+		t.Errorf("Add, Remove: want the same Set if not changed")
+	}
+
+	removed := set.Remove("a").Add("c")
+	if !set.Has("a") || set.Has("c") {
+		t.Errorf("set: changed by removed")
+	}
+	values := removed.Slice()
+	sort.Strings(values)
+	if len(values) != 2 || values[0] != "b" || values[1] != "c" {
+		t.Errorf("Slice: want [b c] got %v", values)
+	}
+
+	collided := NewSet[int](func(value int) uint64 { return 0 }).Add(1).Add(2).Remove(1)
+	if collided.Has(1) || !collided.Has(2) || collided.Len() != 1 {
+		t.Errorf("collisions: want [2] got %v", collided.Slice())
+	}
+}
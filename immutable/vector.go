@@ -0,0 +1,327 @@
+package immutable
+
+import (
+	"sort"
+)
+
+// levelBits of an index for a level of the tries
+const (
+	levelBits = 5
+	width     = 1 << levelBits
+	mask      = width - 1
+)
+
+// Vector is a persistent list, the changes return a new Vector sharing the structure with the old one.
+// it is a relaxed radix balanced tree, the values are inserted and deleted at any index in O(log n).
+// the nil Vector is empty, a Vector can be used as a field of a State and compared by the pointer.
+type Vector[T any] struct {
+	size int
+	root *vectorNode[T]
+}
+
+// vectorNode is a leaf of up to width values or a branch of up to width children, the leaves are at the same depth
+type vectorNode[T any] struct {
+	children []*vectorNode[T]
+	// sizes are the numbers of the values in the children so far, the size table of the branch
+	sizes  []int
+	values []T
+}
+
+// VectorOf creates a Vector of the values.
+func VectorOf[T any](values ...T) *Vector[T] {
+	if len(values) == 0 {
+		return nil
+	}
+
+	nodes := make([]*vectorNode[T], 0, (len(values)+mask)/width)
+	for start := 0; start < len(values); start += width {
+		end := start + width
+		if end > len(values) {
+			end = len(values)
+		}
+		nodes = append(nodes, &vectorNode[T]{values: append([]T(nil), values[start:end]...)})
+	}
+	for len(nodes) > 1 {
+		parents := make([]*vectorNode[T], 0, (len(nodes)+mask)/width)
+		for start := 0; start < len(nodes); start += width {
+			end := start + width
+			if end > len(nodes) {
+				end = len(nodes)
+			}
+			parents = append(parents, newVectorBranch(nodes[start:end:end]))
+		}
+		nodes = parents
+	}
+	return &Vector[T]{size: len(values), root: nodes[0]}
+}
+
+// Len returns the number of the values.
+func (c *Vector[T]) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.size
+}
+
+// Get returns the value at the index, panics if out of range.
+func (c *Vector[T]) Get(index int) T {
+	c.checkIndex(index)
+	node := c.root
+	for !node.leaf() {
+		var child int
+		child, index = node.child(index)
+		node = node.children[child]
+	}
+	return node.values[index]
+}
+
+// Set returns a Vector with the value at the index, panics if out of range.
+func (c *Vector[T]) Set(index int, value T) *Vector[T] {
+	c.checkIndex(index)
+	return &Vector[T]{size: c.size, root: c.root.set(index, value)}
+}
+
+// Append returns a Vector with the value appended.
+func (c *Vector[T]) Append(value T) *Vector[T] {
+	return c.Insert(c.Len(), value)
+}
+
+// Insert returns a Vector with the value inserted at the index, appended if the index is Len, panics if out of range.
+func (c *Vector[T]) Insert(index int, value T) *Vector[T] {
+	if index < 0 || index > c.Len() {
+		panic("immutable: Vector index out of range")
+	}
+	if c == nil {
+		return &Vector[T]{size: 1, root: &vectorNode[T]{values: []T{value}}}
+	}
+
+	root, split := c.root.insert(index, value)
+	if split != nil {
+		root = newVectorBranch([]*vectorNode[T]{root, split})
+	}
+	return &Vector[T]{size: c.size + 1, root: root}
+}
+
+// Delete returns a Vector without the value at the index, panics if out of range.
+func (c *Vector[T]) Delete(index int) *Vector[T] {
+	c.checkIndex(index)
+	if c.size == 1 {
+		return nil
+	}
+
+	root := c.root.delete(index)
+	for !root.leaf() && len(root.children) == 1 {
+		root = root.children[0]
+	}
+	return &Vector[T]{size: c.size - 1, root: root}
+}
+
+// Pop returns a Vector without the last value and the value, panics if empty.
+func (c *Vector[T]) Pop() (*Vector[T], T) {
+	last := c.Len() - 1
+	value := c.Get(last)
+	return c.Delete(last), value
+}
+
+// Range calls fn with the values in order until fn returns false.
+func (c *Vector[T]) Range(fn func(index int, value T) bool) {
+	if c == nil {
+		return
+	}
+	c.root.rangeFrom(0, fn)
+}
+
+// Slice returns the values in a new slice.
+func (c *Vector[T]) Slice() []T {
+	values := make([]T, 0, c.Len())
+	c.Range(func(index int, value T) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+func (c *Vector[T]) checkIndex(index int) {
+	if index < 0 || index >= c.Len() {
+		panic("immutable: Vector index out of range")
+	}
+}
+
+func newVectorBranch[T any](children []*vectorNode[T]) *vectorNode[T] {
+	sizes := make([]int, len(children))
+	total := 0
+	for idx, child := range children {
+		total += child.size()
+		sizes[idx] = total
+	}
+	return &vectorNode[T]{children: children, sizes: sizes}
+}
+
+func (c *vectorNode[T]) leaf() bool {
+	return c.children == nil
+}
+
+// len returns the number of the values of a leaf or the children of a branch
+func (c *vectorNode[T]) len() int {
+	if c.leaf() {
+		return len(c.values)
+	}
+	return len(c.children)
+}
+
+// size returns the number of the values under the node
+func (c *vectorNode[T]) size() int {
+	if c.leaf() {
+		return len(c.values)
+	}
+	return c.sizes[len(c.sizes)-1]
+}
+
+// child returns the child which has the index and the index in the child
+func (c *vectorNode[T]) child(index int) (int, int) {
+	child := sort.SearchInts(c.sizes, index+1)
+	if child > 0 {
+		index -= c.sizes[child-1]
+	}
+	return child, index
+}
+
+func (c *vectorNode[T]) set(index int, value T) *vectorNode[T] {
+	if c.leaf() {
+		values := append([]T(nil), c.values...)
+		values[index] = value
+		return &vectorNode[T]{values: values}
+	}
+	child, childIndex := c.child(index)
+	children := append([]*vectorNode[T](nil), c.children...)
+	children[child] = c.children[child].set(childIndex, value)
+	// the sizes are not changed
+	return &vectorNode[T]{children: children, sizes: c.sizes}
+}
+
+// insert returns the node with the value inserted, and the second half if the node overflows
+func (c *vectorNode[T]) insert(index int, value T) (*vectorNode[T], *vectorNode[T]) {
+	if c.leaf() {
+		values := make([]T, 0, len(c.values)+1)
+		values = append(values, c.values[:index]...)
+		values = append(values, value)
+		values = append(values, c.values[index:]...)
+		if len(values) <= width {
+			return &vectorNode[T]{values: values}, nil
+		}
+		at := splitAt(index, len(c.values))
+		return &vectorNode[T]{values: values[:at:at]}, &vectorNode[T]{values: values[at:]}
+	}
+
+	child, childIndex := len(c.children)-1, 0
+	if index < c.size() {
+		child, childIndex = c.child(index)
+	} else {
+		childIndex = c.children[child].size()
+	}
+	node, split := c.children[child].insert(childIndex, value)
+
+	children := make([]*vectorNode[T], 0, len(c.children)+1)
+	children = append(children, c.children[:child]...)
+	children = append(children, node)
+	if split != nil {
+		children = append(children, split)
+	}
+	children = append(children, c.children[child+1:]...)
+	if len(children) <= width {
+		return newVectorBranch(children), nil
+	}
+	at := splitAt(child+1, len(c.children))
+	return newVectorBranch(children[:at:at]), newVectorBranch(children[at:])
+}
+
+// splitAt returns where to split width+1 items, the left one full if added at the last not to leave half full ones by Append
+func splitAt(index int, count int) int {
+	if index == count {
+		return width
+	}
+	return (width + 1) / 2
+}
+
+// delete returns the node without the value at the index, the children less than half full are joined with a sibling
+func (c *vectorNode[T]) delete(index int) *vectorNode[T] {
+	if c.leaf() {
+		values := make([]T, 0, len(c.values)-1)
+		values = append(values, c.values[:index]...)
+		values = append(values, c.values[index+1:]...)
+		return &vectorNode[T]{values: values}
+	}
+
+	child, childIndex := c.child(index)
+	node := c.children[child].delete(childIndex)
+
+	children := make([]*vectorNode[T], 0, len(c.children))
+	switch {
+	case node.size() == 0:
+		children = append(children, c.children[:child]...)
+		children = append(children, c.children[child+1:]...)
+	case node.len() < width/2 && len(c.children) > 1:
+		left := child
+		if left == len(c.children)-1 {
+			left--
+		}
+		first, second := c.children[left], c.children[left+1]
+		if left == child {
+			first = node
+		} else {
+			second = node
+		}
+		children = append(children, c.children[:left]...)
+		children = append(children, joinVectorNodes(first, second)...)
+		children = append(children, c.children[left+2:]...)
+	default:
+		children = append(children, c.children...)
+		children[child] = node
+	}
+	if len(children) == 0 {
+		return &vectorNode[T]{}
+	}
+	return newVectorBranch(children)
+}
+
+// joinVectorNodes returns the nodes of the same depth joined in one, or two of the halves if overflows
+func joinVectorNodes[T any](first *vectorNode[T], second *vectorNode[T]) []*vectorNode[T] {
+	if first.leaf() {
+		values := make([]T, 0, len(first.values)+len(second.values))
+		values = append(values, first.values...)
+		values = append(values, second.values...)
+		if len(values) <= width {
+			return []*vectorNode[T]{{values: values}}
+		}
+		half := len(values) / 2
+		return []*vectorNode[T]{{values: values[:half:half]}, {values: values[half:]}}
+	}
+
+	children := make([]*vectorNode[T], 0, len(first.children)+len(second.children))
+	children = append(children, first.children...)
+	children = append(children, second.children...)
+	if len(children) <= width {
+		return []*vectorNode[T]{newVectorBranch(children)}
+	}
+	half := len(children) / 2
+	return []*vectorNode[T]{newVectorBranch(children[:half:half]), newVectorBranch(children[half:])}
+}
+
+// rangeFrom calls fn with the values from the index of the first one, returns the next index or -1 if stopped
+func (c *vectorNode[T]) rangeFrom(index int, fn func(index int, value T) bool) int {
+	if c.leaf() {
+		for _, value := range c.values {
+			if !fn(index, value) {
+				return -1
+			}
+			index++
+		}
+		return index
+	}
+	for _, child := range c.children {
+		if index = child.rangeFrom(index, fn); index < 0 {
+			return -1
+		}
+	}
+	return index
+}
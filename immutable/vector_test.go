@@ -0,0 +1,172 @@
+package immutable
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func Test_Vector(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "one leaf", size: 20},
+		{name: "one level", size: 1000},
+		{name: "root splits", size: width*width + width + 1},
+		{name: "three levels", size: width*width*width + 5},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var vector *Vector[int]
+			want := make([]int, 0, tt.size)
+			for idx := 0; idx < tt.size; idx++ {
+				vector = vector.Append(idx)
+				want = append(want, idx)
+			}
+			if vector.Len() != tt.size {
+				t.Fatalf("Len: want %d got %d", tt.size, vector.Len())
+			}
+			for idx := 0; idx < tt.size; idx++ {
+				if got := vector.Get(idx); got != idx {
+					t.Fatalf("Get(%d): got %d", idx, got)
+				}
+			}
+			if got := vector.Slice(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Slice: want %d values got %d", len(want), len(got))
+			}
+
+			// the old versions are not changed
+			updated := vector
+			for idx := 0; idx < tt.size; idx += 7 {
+				updated = updated.Set(idx, -idx)
+			}
+			for idx := 0; idx < tt.size; idx++ {
+				want := idx
+				if idx%7 == 0 {
+					want = -idx
+				}
+				if vector.Get(idx) != idx || updated.Get(idx) != want {
+					t.Fatalf("Set(%d): got %d %d", idx, vector.Get(idx), updated.Get(idx))
+				}
+			}
+		})
+	}
+}
+
+func Test_Vector_Insert_Delete(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	var vector *Vector[int]
+	var want []int
+	for step := 0; step < 20000; step++ {
+		old, oldWant := vector, append([]int(nil), want...)
+		switch index := random.Intn(len(want) + 1); {
+		case len(want) > 0 && random.Intn(5) < 2:
+			if index == len(want) {
+				index--
+			}
+			vector = vector.Delete(index)
+			want = append(want[:index], want[index+1:]...)
+		case random.Intn(4) == 0:
+			vector = vector.Append(step)
+			want = append(want, step)
+		default:
+			vector = vector.Insert(index, step)
+			want = append(want[:index], append([]int{step}, want[index:]...)...)
+		}
+
+		if vector.Len() != len(want) {
+			t.Fatalf("step %d: Len want %d got %d", step, len(want), vector.Len())
+		}
+		if step%100 == 0 || vector.Len() < width*2 {
+			if got := vector.Slice(); len(want) > 0 && !reflect.DeepEqual(got, want) {
+				t.Fatalf("step %d: want %v got %v", step, want, got)
+			}
+			for idx := range want {
+				if got := vector.Get(idx); got != want[idx] {
+					t.Fatalf("step %d: Get(%d) want %d got %d", step, idx, want[idx], got)
+				}
+			}
+			// the old version is not changed
+			if got := old.Slice(); len(oldWant) > 0 && !reflect.DeepEqual(got, oldWant) {
+				t.Fatalf("step %d: old want %v got %v", step, oldWant, got)
+			}
+		}
+	}
+
+	t.Run("delete all", func(t *testing.T) {
+		vector := VectorOf(make([]int, width*width*2)...)
+		for vector.Len() > 0 {
+			vector = vector.Delete(random.Intn(vector.Len()))
+		}
+		if vector != nil {
+			t.Errorf("Delete: want nil if empty")
+		}
+	})
+}
+
+func Test_Vector_Pop(t *testing.T) {
+	vector := VectorOf(1, 2, 3)
+
+	popped, value := vector.Pop()
+	if value != 3 || !reflect.DeepEqual(popped.Slice(), []int{1, 2}) {
+		t.Errorf("Pop: got %v %d", popped.Slice(), value)
+	}
+	if !reflect.DeepEqual(vector.Slice(), []int{1, 2, 3}) {
+		t.Errorf("Pop: want the old one not changed got %v", vector.Slice())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Pop: want panic if empty")
+		}
+	}()
+	var empty *Vector[int]
+	empty.Pop()
+}
+
+func Test_VectorOf(t *testing.T) {
+	values := make([]int, width*width*3+7)
+	for idx := range values {
+		values[idx] = idx
+	}
+	vector := VectorOf(values...)
+	if got := vector.Slice(); !reflect.DeepEqual(got, values) {
+		t.Errorf("VectorOf: want %d values got %d", len(values), len(got))
+	}
+	if got := vector.Insert(width, -1).Delete(0).Get(width - 1); got != -1 {
+		t.Errorf("Insert: want -1 got %d", got)
+	}
+}
+
+func Test_Vector_Range(t *testing.T) {
+	vector := VectorOf("a", "b", "c")
+
+	var got []string
+	vector.Range(func(index int, value string) bool {
+		got = append(got, value)
+		return index < 1
+	})
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Range: want stopped at b got %v", got)
+	}
+
+	var empty *Vector[string]
+	empty.Range(func(index int, value string) bool {
+		t.Errorf("Range: empty called with %d", index)
+		return true
+	})
+}
+
+func Test_Vector_OutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Get: want panic")
+		}
+	}()
+	VectorOf(1).Get(1)
+}
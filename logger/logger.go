@@ -1,9 +1,13 @@
 package logger
 
-import "log"
+import (
+	"log"
+	"sync/atomic"
+)
 
 var (
-	logEnabled bool = false
+	// logEnabled can be changed while logging in other goroutines
+	logEnabled atomic.Bool
 )
 
 type Level string
@@ -14,7 +18,7 @@ var INFO Level = "INFO"
 var DEBUG Level = "DEBU"
 
 func SetLogEnable(enable bool) {
-	logEnabled = enable
+	logEnabled.Store(enable)
 }
 
 func LogForcedf(fmt string, args ...interface{}) {
@@ -22,7 +26,7 @@ func LogForcedf(fmt string, args ...interface{}) {
 }
 
 func Logf(level string, fmt string, args ...interface{}) {
-	if !logEnabled {
+	if !logEnabled.Load() {
 		return
 	}
 	log.Printf(level+" "+fmt, args...)
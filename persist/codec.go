@@ -0,0 +1,43 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+// Codec encodes and decodes the state.
+type Codec[S store.State] interface {
+	Encode(state S) ([]byte, error)
+	Decode(data []byte) (S, error)
+}
+
+// JSONCodec encodes exported fields of the state in JSON.
+type JSONCodec[S store.State] struct{}
+
+func (c JSONCodec[S]) Encode(state S) ([]byte, error) {
+	return json.Marshal(state)
+}
+
+func (c JSONCodec[S]) Decode(data []byte) (state S, err error) {
+	err = json.Unmarshal(data, &state)
+	return
+}
+
+// GobCodec encodes exported fields of the state in gob.
+type GobCodec[S store.State] struct{}
+
+func (c GobCodec[S]) Encode(state S) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := gob.NewEncoder(buffer).Encode(state); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (c GobCodec[S]) Decode(data []byte) (state S, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&state)
+	return
+}
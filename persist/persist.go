@@ -0,0 +1,274 @@
+package persist
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rookiecj/go-store/logger"
+	"github.com/rookiecj/go-store/sched"
+	"github.com/rookiecj/go-store/store"
+)
+
+var (
+	// ErrNoMigration is returned when there is no migration from the saved version.
+	ErrNoMigration = errors.New("no migration")
+
+	// ErrNewerVersion is returned when the saved version is newer than the current one.
+	ErrNewerVersion = errors.New("newer version")
+
+	// ErrBadHeader is returned when the file is not saved by persist.
+	ErrBadHeader = errors.New("bad header")
+)
+
+// header is the first line of the file followed by the encoded state
+const header = "go-store/persist v%d\n"
+
+// Migration migrates the encoded state of a version to the next version.
+type Migration func(data []byte) ([]byte, error)
+
+// Options configures where and how the state is saved.
+type Options[S store.State] struct {
+	// Path of the file
+	Path string
+
+	// Codec encodes the state, JSONCodec if nil
+	Codec Codec[S]
+
+	// Version of the schema of the state
+	Version int
+
+	// Migrations migrate the state saved in a version(the key) to the next version.
+	Migrations map[int]Migration
+
+	// Debounce waits for changes to settle before saving, saves as soon as changed if 0.
+	// the state is saved in the background not to block reducers, the latest one if changed while saving.
+	Debounce time.Duration
+
+	// MaxDelay is the longest time a change waits to be saved while debouncing, no limit if 0
+	MaxDelay time.Duration
+}
+
+func (c *Options[S]) codec() Codec[S] {
+	if c.Codec == nil {
+		return JSONCodec[S]{}
+	}
+	return c.Codec
+}
+
+// Persister saves the state of a store on change.
+type Persister[S store.State] struct {
+	options  Options[S]
+	disposer store.Disposer
+
+	lock  sync.Mutex
+	state S
+	dirty bool
+	timer *time.Timer
+	// first change not saved
+	since time.Time
+	err   error
+	// not to save the changes notified after Close
+	closed bool
+
+	// saves one by one
+	saveLock sync.Mutex
+}
+
+// NewStore rehydrates the state and creates a store saving the state on change.
+func NewStore[S store.State](initialState S, reducer store.Reducer[S], options Options[S]) (store.Store[S], *Persister[S], error) {
+	return NewStoreOn(sched.NewMainScheduler(), initialState, reducer, options)
+}
+
+// NewStoreOn rehydrates the state from the file and creates a store on the scheduler saving the state on change.
+// initialState is used if the file does not exist.
+func NewStoreOn[S store.State](scheduler sched.Scheduler, initialState S, reducer store.Reducer[S], options Options[S]) (store.Store[S], *Persister[S], error) {
+	state, found, err := Load(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		state = initialState
+	}
+
+	stateStore := store.NewStoreOn(scheduler, state, reducer)
+	persister := &Persister[S]{
+		options: options,
+	}
+	persister.disposer = stateStore.Subscribe(func(newState S, oldState S, action store.Action) {
+		if _, ok := action.(*store.InitAction); ok {
+			// rehydrated
+			return
+		}
+		persister.changed(newState)
+	})
+	return stateStore, persister, nil
+}
+
+// changed schedules to save the state, not saved in the dispatch context
+func (c *Persister[S]) changed(state S) {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return
+	}
+	c.state = state
+	if !c.dirty {
+		c.dirty = true
+		c.since = time.Now()
+	}
+
+	delay := c.options.Debounce
+	if c.options.MaxDelay > 0 {
+		if remains := c.options.MaxDelay - time.Since(c.since); remains < delay {
+			delay = remains
+		}
+	}
+	if c.timer == nil {
+		c.timer = time.AfterFunc(delay, func() {
+			c.Flush()
+		})
+	} else {
+		c.timer.Reset(delay)
+	}
+	c.lock.Unlock()
+}
+
+// Flush saves the state changed if any.
+func (c *Persister[S]) Flush() error {
+	c.saveLock.Lock()
+	defer c.saveLock.Unlock()
+
+	c.lock.Lock()
+	if !c.dirty {
+		err := c.err
+		c.lock.Unlock()
+		return err
+	}
+	state := c.state
+	c.dirty = false
+	c.lock.Unlock()
+
+	err := Save(c.options, state)
+	if err != nil {
+		logger.Errf("persist: save %s: %s\n", c.options.Path, err)
+	}
+
+	c.lock.Lock()
+	c.err = err
+	c.lock.Unlock()
+	return err
+}
+
+// Err returns the error of the last save.
+func (c *Persister[S]) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+// Close stops saving and saves the last state, nothing is saved after Close returned.
+// it should be called after the store stopped not to miss changes, and not in a subscriber.
+func (c *Persister[S]) Close() error {
+	// the notification running is saved
+	c.disposer.DisposeAndWait()
+
+	c.lock.Lock()
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.lock.Unlock()
+
+	return c.Flush()
+}
+
+// Load reads the state from the file and migrates it to the current version.
+// found is false if the file does not exist.
+func Load[S store.State](options Options[S]) (state S, found bool, err error) {
+	file, err := os.Open(options.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrBadHeader, err)
+		return
+	}
+	var version int
+	if _, err = fmt.Sscanf(line, header, &version); err != nil {
+		err = fmt.Errorf("%w: %s", ErrBadHeader, err)
+		return
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return
+	}
+
+	if version > options.Version {
+		err = fmt.Errorf("%w: %d > %d", ErrNewerVersion, version, options.Version)
+		return
+	}
+	for ; version < options.Version; version++ {
+		migration, ok := options.Migrations[version]
+		if !ok {
+			err = fmt.Errorf("%w: from version %d", ErrNoMigration, version)
+			return
+		}
+		if data, err = migration(data); err != nil {
+			err = fmt.Errorf("migrate from version %d: %w", version, err)
+			return
+		}
+	}
+
+	state, err = options.codec().Decode(data)
+	found = err == nil
+	return
+}
+
+// Save writes the state to a temporary file and renames it to the file.
+func Save[S store.State](options Options[S], state S) error {
+	data, err := options.codec().Encode(state)
+	if err != nil {
+		return err
+	}
+
+	buffer := bytes.NewBufferString(fmt.Sprintf(header, options.Version))
+	buffer.Write(data)
+
+	dir, base := filepath.Split(options.Path)
+	if dir == "" {
+		dir = "."
+	}
+	temp, err := os.CreateTemp(dir, base+".tmp*")
+	if err != nil {
+		return err
+	}
+	// remove the temporary file unless renamed
+	defer os.Remove(temp.Name())
+
+	if _, err = temp.Write(buffer.Bytes()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), options.Path)
+}
@@ -0,0 +1,290 @@
+package persist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+type todoState struct {
+	Title string
+	Items []string
+}
+
+func (c todoState) StateInterface() {}
+
+type addItemAction struct {
+	item string
+}
+
+func todoReducer(state todoState, action store.Action) (todoState, error) {
+	switch reified := action.(type) {
+	case *addItemAction:
+		items := append(append([]string{}, state.Items...), reified.item)
+		return todoState{Title: state.Title, Items: items}, nil
+	}
+	return state, nil
+}
+
+// countingCodec counts encoding
+type countingCodec[S store.State] struct {
+	Codec[S]
+	encoded int64
+}
+
+func (c *countingCodec[S]) Encode(state S) ([]byte, error) {
+	atomic.AddInt64(&c.encoded, 1)
+	return c.Codec.Encode(state)
+}
+
+// blockingCodec encodes after blocked is closed
+type blockingCodec[S store.State] struct {
+	Codec[S]
+	blocked chan struct{}
+}
+
+func (c *blockingCodec[S]) Encode(state S) ([]byte, error) {
+	<-c.blocked
+	return c.Codec.Encode(state)
+}
+
+func Test_NewStore_Rehydrate(t *testing.T) {
+
+	type testCasePersist struct {
+		name  string
+		codec Codec[todoState]
+	}
+
+	tests := []testCasePersist{
+		{
+			name:  "json",
+			codec: nil,
+		},
+		{
+			name:  "gob",
+			codec: GobCodec[todoState]{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			options := Options[todoState]{
+				Path:    filepath.Join(t.TempDir(), "todo."+tt.name),
+				Codec:   tt.codec,
+				Version: 1,
+			}
+			initialState := todoState{Title: "todo"}
+
+			first, persister, err := NewStore(initialState, todoReducer, options)
+			if err != nil {
+				t.Fatalf("NewStore: %v", err)
+			}
+			first.Dispatch(&addItemAction{"a"})
+			first.Dispatch(&addItemAction{"b"})
+			first.Stop()
+			first.WaitForStore()
+			if err := persister.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			second, persister, err := NewStore(initialState, todoReducer, options)
+			if err != nil {
+				t.Fatalf("NewStore: %v", err)
+			}
+			second.Dispatch(&addItemAction{"c"})
+			second.Stop()
+			second.WaitForStore()
+			persister.Close()
+
+			got := second.GetState()
+			if got.Title != "todo" || len(got.Items) != 3 || got.Items[2] != "c" {
+				t.Errorf("Rehydrate: got %v", got)
+			}
+		})
+	}
+}
+
+func Test_NewStore_Debounce(t *testing.T) {
+
+	t.Run("save once for changes in a row", func(t *testing.T) {
+		codec := &countingCodec[todoState]{Codec: JSONCodec[todoState]{}}
+		options := Options[todoState]{
+			Path:     filepath.Join(t.TempDir(), "todo.json"),
+			Codec:    codec,
+			Debounce: 50 * time.Millisecond,
+		}
+
+		s, persister, err := NewStore(todoState{}, todoReducer, options)
+		if err != nil {
+			t.Fatalf("NewStore: %v", err)
+		}
+		for idx := 0; idx < 10; idx++ {
+			s.Dispatch(&addItemAction{"x"})
+		}
+		time.Sleep(200 * time.Millisecond)
+
+		if got := atomic.LoadInt64(&codec.encoded); got != 1 {
+			t.Errorf("Debounce: saved want %d got %d", 1, got)
+		}
+
+		s.Stop()
+		s.WaitForStore()
+		persister.Close()
+
+		state, found, err := Load(options)
+		if err != nil || !found || len(state.Items) != 10 {
+			t.Errorf("Load: got %v, %v, %v", state, found, err)
+		}
+	})
+
+	t.Run("not saved in the dispatch context", func(t *testing.T) {
+		blocked := make(chan struct{})
+		options := Options[todoState]{
+			Path:  filepath.Join(t.TempDir(), "todo.json"),
+			Codec: &blockingCodec[todoState]{Codec: JSONCodec[todoState]{}, blocked: blocked},
+		}
+
+		s, persister, err := NewStore(todoState{}, todoReducer, options)
+		if err != nil {
+			t.Fatalf("NewStore: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		for idx := 0; idx < 3; idx++ {
+			// the subscribers are notified before returned
+			if _, _, err := s.DispatchSync(ctx, &addItemAction{"x"}); err != nil {
+				t.Fatalf("DispatchSync: blocked by saving: %v", err)
+			}
+		}
+		close(blocked)
+
+		s.Stop()
+		s.WaitForStore()
+		persister.Close()
+
+		state, found, err := Load(options)
+		if err != nil || !found || len(state.Items) != 3 {
+			t.Errorf("Load: got %v, %v, %v", state, found, err)
+		}
+	})
+}
+
+func Test_Persister_Close(t *testing.T) {
+
+	t.Run("nothing saved after Close", func(t *testing.T) {
+		options := Options[todoState]{
+			Path: filepath.Join(t.TempDir(), "todo.json"),
+		}
+
+		s, persister, err := NewStore(todoState{}, todoReducer, options)
+		if err != nil {
+			t.Fatalf("NewStore: %v", err)
+		}
+		s.Dispatch(&addItemAction{"a"})
+		s.Stop()
+		s.WaitForStore()
+		if err := persister.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		// notified late
+		persister.changed(todoState{Items: []string{"late"}})
+		if err := persister.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+
+		state, found, err := Load(options)
+		if err != nil || !found || len(state.Items) != 1 || state.Items[0] != "a" {
+			t.Errorf("Load: got %v, %v, %v", state, found, err)
+		}
+	})
+}
+
+func Test_Load_Migrate(t *testing.T) {
+
+	type args struct {
+		saved   string
+		options Options[todoState]
+	}
+	type testCaseMigrate struct {
+		name    string
+		args    args
+		want    todoState
+		wantErr error
+	}
+
+	renameName := func(data []byte) ([]byte, error) {
+		return bytes.Replace(data, []byte(`"Name"`), []byte(`"Title"`), 1), nil
+	}
+
+	tests := []testCaseMigrate{
+		{
+			name: "migrate v1 to v2",
+			args: args{
+				saved: "go-store/persist v1\n" + `{"Name":"old"}`,
+				options: Options[todoState]{
+					Version:    2,
+					Migrations: map[int]Migration{1: renameName},
+				},
+			},
+			want: todoState{Title: "old"},
+		},
+		{
+			name: "no migration",
+			args: args{
+				saved: "go-store/persist v0\n" + `{"Name":"old"}`,
+				options: Options[todoState]{
+					Version:    2,
+					Migrations: map[int]Migration{1: renameName},
+				},
+			},
+			wantErr: ErrNoMigration,
+		},
+		{
+			name: "newer version",
+			args: args{
+				saved: "go-store/persist v3\n{}",
+				options: Options[todoState]{
+					Version: 2,
+				},
+			},
+			wantErr: ErrNewerVersion,
+		},
+		{
+			name: "bad header",
+			args: args{
+				saved: "{}",
+				options: Options[todoState]{
+					Version: 2,
+				},
+			},
+			wantErr: ErrBadHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.options.Path = filepath.Join(t.TempDir(), "todo.json")
+			if err := os.WriteFile(tt.args.options.Path, []byte(tt.args.saved), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _, err := Load(tt.args.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load: error want %v got %v", tt.wantErr, err)
+			}
+			if got.Title != tt.want.Title {
+				t.Errorf("Load: want %v got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+	"github.com/rookiecj/go-store/store"
+)
+
+// ErrPanic is wrapped by the error of a saga panicked.
+var ErrPanic = errors.New("saga panics")
+
+// Saga is a workflow run by Runner, it should return when the context of effects is done.
+type Saga[S store.State] func(effects *Effects[S]) error
+
+// Pattern matches an action to take.
+type Pattern func(action store.Action) bool
+
+// Type matches the actions of type A, like *addAction.
+func Type[A any]() Pattern {
+	actionType := reflect.TypeOf((*A)(nil)).Elem()
+	return func(action store.Action) bool {
+		return action != nil && reflect.TypeOf(action) == actionType
+	}
+}
+
+// Any matches any action.
+func Any() Pattern {
+	return func(action store.Action) bool {
+		return true
+	}
+}
+
+// OneOf matches an action matched by one of the patterns.
+func OneOf(patterns ...Pattern) Pattern {
+	return func(action store.Action) bool {
+		for _, pattern := range patterns {
+			if pattern(action) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Runner runs sagas with a store, sagas see the actions after reduced.
+type Runner[S store.State] struct {
+	store store.Store[S]
+
+	lock   sync.Mutex
+	takers []*taker
+	// added is called with the number of the takers when a taker is added, to know a saga is taking in tests
+	added func(takers int)
+}
+
+type taker struct {
+	pattern Pattern
+	taken   chan store.Action
+}
+
+// NewRunner creates Runner with a middleware applied to the store.
+func NewRunner[S store.State](st store.Store[S]) *Runner[S] {
+	runner := &Runner[S]{
+		store: st,
+	}
+	store.ApplyMiddleware(st, runner.middleware)
+	return runner
+}
+
+// Run runs the saga in the background, the saga is cancelled when the store is stopping
+// and WaitForStore waits for it.
+func (c *Runner[S]) Run(saga Saga[S]) *Task {
+	future := store.RunThunk(c.store, sched.Background, func(ctx context.Context, dispatch store.Dispatcher, getState func() S) (struct{}, error) {
+		return struct{}{}, c.run(ctx, saga)
+	})
+	return &Task{
+		done:   future.Done(),
+		cancel: future.Cancel,
+		result: func() error {
+			_, err := future.Result()
+			return err
+		},
+	}
+}
+
+// middleware passes the action to the takers after reduced
+func (c *Runner[S]) middleware(api store.MiddlewareAPI[S], next store.DispatchFunc, action store.Action) {
+	next(action)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var remains []*taker
+	for _, taker := range c.takers {
+		if taker.pattern(action) {
+			// taken once
+			taker.taken <- action
+			continue
+		}
+		remains = append(remains, taker)
+	}
+	c.takers = remains
+}
+
+func (c *Runner[S]) addTaker(taker *taker) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.takers = append(c.takers, taker)
+	if c.added != nil {
+		c.added(len(c.takers))
+	}
+}
+
+func (c *Runner[S]) removeTaker(taker *taker) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for idx := 0; idx < len(c.takers); idx++ {
+		if c.takers[idx] == taker {
+			c.takers = append(c.takers[:idx:idx], c.takers[idx+1:]...)
+			break
+		}
+	}
+}
+
+// run runs the saga and waits for the sagas forked, the first error cancels the others
+func (c *Runner[S]) run(ctx context.Context, saga Saga[S]) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	effects := &Effects[S]{
+		ctx:    ctx,
+		runner: c,
+	}
+	effects.fail = func(err error) {
+		effects.lock.Lock()
+		if effects.failure == nil {
+			effects.failure = err
+		}
+		effects.lock.Unlock()
+		cancel()
+	}
+
+	err = callSaga(saga, effects)
+	if err != nil {
+		cancel()
+	}
+	effects.forks.Wait()
+
+	effects.lock.Lock()
+	defer effects.lock.Unlock()
+	if effects.failure != nil {
+		return effects.failure
+	}
+	return err
+}
+
+func callSaga[S store.State](saga Saga[S], effects *Effects[S]) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+	}()
+	return saga(effects)
+}
+
+// Task is a saga running.
+type Task struct {
+	done   <-chan struct{}
+	cancel func()
+	result func() error
+}
+
+// Done is closed when the saga and its forks returned.
+func (c *Task) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait waits for the saga and returns its error.
+func (c *Task) Wait() error {
+	<-c.done
+	return c.result()
+}
+
+// Cancel cancels the saga and its forks.
+func (c *Task) Cancel() {
+	c.cancel()
+}
+
+// Effects is what a saga can do, it is valid while the saga runs.
+type Effects[S store.State] struct {
+	ctx    context.Context
+	runner *Runner[S]
+
+	// forks of the saga
+	forks sync.WaitGroup
+	// fail cancels the saga with the error of a fork
+	fail    func(err error)
+	lock    sync.Mutex
+	failure error
+}
+
+// Context is done when the saga is cancelled.
+func (c *Effects[S]) Context() context.Context {
+	return c.ctx
+}
+
+// Take waits for an action matched, the actions dispatched before are not taken.
+func (c *Effects[S]) Take(pattern Pattern) (store.Action, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	taker := &taker{
+		pattern: pattern,
+		taken:   make(chan store.Action, 1),
+	}
+	c.runner.addTaker(taker)
+	select {
+	case action := <-taker.taken:
+		return action, nil
+	case <-c.ctx.Done():
+		c.runner.removeTaker(taker)
+		// taken at the same time
+		select {
+		case action := <-taker.taken:
+			return action, nil
+		default:
+		}
+		return nil, c.ctx.Err()
+	}
+}
+
+// TakeType waits for an action of type A.
+func TakeType[A any, S store.State](effects *Effects[S]) (A, error) {
+	action, err := effects.Take(Type[A]())
+	if err != nil {
+		var zero A
+		return zero, err
+	}
+	return action.(A), nil
+}
+
+// Put dispatches the action.
+func (c *Effects[S]) Put(action store.Action) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.runner.store.TryDispatch(action)
+}
+
+// Dispatch dispatches the action, Effects is a store.Dispatcher like for Transaction.
+func (c *Effects[S]) Dispatch(action store.Action) {
+	c.runner.store.Dispatch(action)
+}
+
+// Select returns the state.
+func (c *Effects[S]) Select() S {
+	return c.runner.store.GetState()
+}
+
+// Delay waits for the duration.
+func (c *Effects[S]) Delay(duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
+
+// Fork runs the saga attached to this saga,
+// this saga returns after the forks returned, an error of a fork cancels this saga.
+func (c *Effects[S]) Fork(saga Saga[S]) *Task {
+	ctx, cancel := context.WithCancel(c.ctx)
+	done := make(chan struct{})
+	var err error
+
+	c.forks.Add(1)
+	go func() {
+		defer c.forks.Done()
+		defer close(done)
+		err = c.runner.run(ctx, saga)
+		cancel()
+		if err != nil && !isCancelled(ctx, err) {
+			c.fail(err)
+		}
+	}()
+
+	return &Task{
+		done:   done,
+		cancel: cancel,
+		result: func() error {
+			return err
+		},
+	}
+}
+
+// Race runs the sagas and returns the index and the error of the first one returned,
+// the others are cancelled and waited.
+func (c *Effects[S]) Race(sagas ...Saga[S]) (int, error) {
+	if len(sagas) == 0 {
+		return -1, nil
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	type result struct {
+		index int
+		err   error
+	}
+	results := make(chan result, len(sagas))
+	for idx, saga := range sagas {
+		idx, saga := idx, saga
+		go func() {
+			results <- result{idx, c.runner.run(ctx, saga)}
+		}()
+	}
+
+	winner := <-results
+	cancel()
+	for remains := len(sagas) - 1; remains > 0; remains-- {
+		<-results
+	}
+	if winner.err == nil && c.ctx.Err() != nil {
+		// cancelled with the race
+		return winner.index, c.ctx.Err()
+	}
+	return winner.index, winner.err
+}
+
+// isCancelled reports whether err is from the context cancelled
+func isCancelled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
@@ -0,0 +1,261 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+type counterState struct {
+	count int
+}
+
+func (c counterState) StateInterface() {}
+
+type incrementAction struct{}
+
+type fetchAction struct{}
+
+type fetchedAction struct {
+	count int
+}
+
+type cancelAction struct{}
+
+func counterReducer(state counterState, action store.Action) (counterState, error) {
+	switch reified := action.(type) {
+	case *incrementAction:
+		state.count++
+	case *fetchedAction:
+		state.count = reified.count
+	}
+	return state, nil
+}
+
+func newCounterStore() store.Store[counterState] {
+	return store.NewStore(counterState{}, counterReducer)
+}
+
+// newTakingRunner returns a runner with the numbers of the takers added
+func newTakingRunner(st store.Store[counterState]) (*Runner[counterState], <-chan int) {
+	runner := NewRunner(st)
+	taking := make(chan int, 16)
+	runner.added = func(takers int) {
+		taking <- takers
+	}
+	return runner, taking
+}
+
+// waitTaking waits until the takers are added as many as count
+func waitTaking(t *testing.T, taking <-chan int, count int) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case takers := <-taking:
+			if takers >= count {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Take: want %d takers", count)
+		}
+	}
+}
+
+// waitTask waits for the task with a deadline
+func waitTask(t *testing.T, task *Task) error {
+	t.Helper()
+	select {
+	case <-task.Done():
+		return task.Wait()
+	case <-time.After(time.Second):
+		t.Fatalf("Wait: timeout")
+		return nil
+	}
+}
+
+func Test_Runner(t *testing.T) {
+
+	t.Run("take and put", func(t *testing.T) {
+		st := newCounterStore()
+		runner, taking := newTakingRunner(st)
+
+		task := runner.Run(func(effects *Effects[counterState]) error {
+			if _, err := TakeType[*fetchAction](effects); err != nil {
+				return err
+			}
+			// reduced before taken
+			state := effects.Select()
+			return effects.Put(&fetchedAction{count: state.count + 10})
+		})
+
+		waitTaking(t, taking, 1)
+		st.Dispatch(&incrementAction{})
+		st.Dispatch(&fetchAction{})
+		if err := waitTask(t, task); err != nil {
+			t.Errorf("Wait: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := store.WaitFor(ctx, st, func(state counterState) bool {
+			return state.count == 11
+		})
+		if err != nil {
+			t.Errorf("WaitFor: %v", err)
+		}
+		st.Stop()
+		st.WaitForStore()
+	})
+
+	t.Run("race", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			cancel    bool
+			wantIndex int
+		}{
+			{name: "timeout", wantIndex: 1},
+			{name: "cancelled", cancel: true, wantIndex: 0},
+		}
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				st := newCounterStore()
+				runner, taking := newTakingRunner(st)
+
+				var gotIndex int
+				task := runner.Run(func(effects *Effects[counterState]) error {
+					var err error
+					gotIndex, err = effects.Race(
+						func(effects *Effects[counterState]) error {
+							_, err := effects.Take(Type[*cancelAction]())
+							return err
+						},
+						func(effects *Effects[counterState]) error {
+							return effects.Delay(100 * time.Millisecond)
+						},
+					)
+					return err
+				})
+
+				waitTaking(t, taking, 1)
+				if tt.cancel {
+					st.Dispatch(&cancelAction{})
+				}
+				if err := waitTask(t, task); err != nil {
+					t.Errorf("Wait: %v", err)
+				}
+				if gotIndex != tt.wantIndex {
+					t.Errorf("Race: want %d got %d", tt.wantIndex, gotIndex)
+				}
+				st.Stop()
+				st.WaitForStore()
+			})
+		}
+	})
+
+	t.Run("fork takes every action", func(t *testing.T) {
+		st := newCounterStore()
+		runner, taking := newTakingRunner(st)
+
+		var taken int
+		task := runner.Run(func(effects *Effects[counterState]) error {
+			fork := effects.Fork(func(effects *Effects[counterState]) error {
+				for {
+					if _, err := effects.Take(Type[*incrementAction]()); err != nil {
+						return err
+					}
+					taken++
+				}
+			})
+			if _, err := effects.Take(Type[*cancelAction]()); err != nil {
+				return err
+			}
+			fork.Cancel()
+			return nil
+		})
+
+		// the saga and the fork
+		waitTaking(t, taking, 2)
+		// taken one by one
+		for i := 0; i < 3; i++ {
+			st.Dispatch(&incrementAction{})
+			waitTaking(t, taking, 2)
+		}
+		st.Dispatch(&cancelAction{})
+
+		if err := waitTask(t, task); err != nil {
+			t.Errorf("Wait: %v", err)
+		}
+		if taken != 3 {
+			t.Errorf("Take: want 3 got %d", taken)
+		}
+		st.Stop()
+		st.WaitForStore()
+	})
+
+	t.Run("fork fails", func(t *testing.T) {
+		st := newCounterStore()
+		runner := NewRunner(st)
+
+		errFork := errors.New("fork fails")
+		task := runner.Run(func(effects *Effects[counterState]) error {
+			effects.Fork(func(effects *Effects[counterState]) error {
+				return errFork
+			})
+			// cancelled by the fork
+			_, err := effects.Take(Any())
+			return err
+		})
+
+		if err := waitTask(t, task); !errors.Is(err, errFork) {
+			t.Errorf("Wait: want %v got %v", errFork, err)
+		}
+		st.Stop()
+		st.WaitForStore()
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		st := newCounterStore()
+		runner := NewRunner(st)
+
+		task := runner.Run(func(effects *Effects[counterState]) error {
+			panic("saga panics")
+		})
+		if err := waitTask(t, task); !errors.Is(err, ErrPanic) {
+			t.Errorf("Wait: want %v got %v", ErrPanic, err)
+		}
+		st.Stop()
+		st.WaitForStore()
+	})
+
+	t.Run("cancelled by Stop", func(t *testing.T) {
+		st := newCounterStore()
+		runner, taking := newTakingRunner(st)
+
+		task := runner.Run(func(effects *Effects[counterState]) error {
+			effects.Fork(func(effects *Effects[counterState]) error {
+				return effects.Delay(time.Hour)
+			})
+			_, err := effects.Take(Any())
+			return err
+		})
+
+		waitTaking(t, taking, 1)
+		st.Stop()
+		// waits for the saga
+		st.WaitForStore()
+
+		select {
+		case <-task.Done():
+		default:
+			t.Fatalf("Done: want closed")
+		}
+		if err := waitTask(t, task); !errors.Is(err, context.Canceled) {
+			t.Errorf("Wait: want %v got %v", context.Canceled, err)
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rookiecj/go-store/sched"
+	"github.com/rookiecj/go-store/store"
+)
+
+var (
+	// ErrTxMismatch is returned by Resume when the record is not of the transaction.
+	ErrTxMismatch = errors.New("transaction mismatch")
+	// ErrTxInterrupted is returned by RunTransaction when the store rejected the actions of the transaction.
+	ErrTxInterrupted = errors.New("transaction interrupted")
+)
+
+// TxStatus is the progress of a transaction.
+type TxStatus int
+
+const (
+	// TxRunning is doing the steps
+	TxRunning TxStatus = iota
+	// TxCommitted did all the steps
+	TxCommitted
+	// TxCompensating is undoing the steps done
+	TxCompensating
+	// TxCompensated undid all the steps done
+	TxCompensated
+)
+
+func (c TxStatus) String() string {
+	switch c {
+	case TxRunning:
+		return "running"
+	case TxCommitted:
+		return "committed"
+	case TxCompensating:
+		return "compensating"
+	case TxCompensated:
+		return "compensated"
+	}
+	return "unknown"
+}
+
+// Step is a step of a transaction.
+type Step struct {
+	Name string
+	// Do does the step, like dispatching an action and waiting for the state
+	Do func(ctx context.Context, dispatch store.Dispatcher) error
+	// Compensation is dispatched to undo the step when a later step fails, nothing to undo if nil
+	Compensation store.Action
+}
+
+// Transaction is steps done all, or undone in reverse order by their compensations.
+// the progress is dispatched with TxBeginAction, TxStepAction, TxCompensateAction and TxEndAction,
+// reduced by ReduceTxLog into TxLog in the state to Resume after restarted.
+type Transaction struct {
+	Name  string
+	Steps []Step
+}
+
+// TxError is the error of a step compensated.
+type TxError struct {
+	ID   string
+	Step string
+	Err  error
+}
+
+func (c *TxError) Error() string {
+	return fmt.Sprintf("transaction %s: step %s: %v", c.ID, c.Step, c.Err)
+}
+
+func (c *TxError) Unwrap() error {
+	return c.Err
+}
+
+// Run does the steps in order, a step failed or ctx done compensates the steps done.
+// the compensations are dispatched even if ctx is done, returns *TxError when compensated.
+func (c *Transaction) Run(ctx context.Context, id string, dispatch store.Dispatcher) error {
+	dispatch.Dispatch(&TxBeginAction{ID: id, Name: c.Name})
+	return c.run(ctx, id, dispatch, nil)
+}
+
+// RunTransaction runs the transaction as a thunk of the store, WaitForStore waits for it.
+// Stop of the store or ctx done compensates the steps done, the actions rejected by the store after stopping
+// are returned as ErrTxInterrupted, TxLog has the progress recorded to Resume after restarted.
+func RunTransaction[S store.State](ctx context.Context, st store.Store[S], tx *Transaction, id string) *Task {
+	future := store.RunThunk(st, sched.Background, func(storeCtx context.Context, dispatch store.Dispatcher, getState func() S) (struct{}, error) {
+		storeCtx, cancel := context.WithCancel(storeCtx)
+		defer cancel()
+		go func() {
+			select {
+			case <-ctx.Done():
+				cancel()
+			case <-storeCtx.Done():
+			}
+		}()
+
+		dispatcher := &tryDispatcher[S]{store: st}
+		err := tx.Run(storeCtx, id, dispatcher)
+		if rejected := dispatcher.rejected(); rejected != nil {
+			return struct{}{}, fmt.Errorf("%w: %s: %v, %v", ErrTxInterrupted, id, rejected, err)
+		}
+		return struct{}{}, err
+	})
+	return &Task{
+		done:   future.Done(),
+		cancel: future.Cancel,
+		result: func() error {
+			_, err := future.Result()
+			return err
+		},
+	}
+}
+
+// tryDispatcher dispatches with TryDispatch not to lose the error
+type tryDispatcher[S store.State] struct {
+	store store.Store[S]
+	lock  sync.Mutex
+	err   error
+}
+
+func (c *tryDispatcher[S]) Dispatch(action store.Action) {
+	if err := c.store.TryDispatch(action); err != nil {
+		c.lock.Lock()
+		if c.err == nil {
+			c.err = err
+		}
+		c.lock.Unlock()
+	}
+}
+
+// rejected returns the first error dispatching
+func (c *tryDispatcher[S]) rejected() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+// Resume continues the transaction recorded, like after restarted.
+// running one does the rest steps if forward, or compensates the steps done.
+// compensating one compensates the rest steps, the compensation of the last step may be dispatched twice.
+func (c *Transaction) Resume(ctx context.Context, record TxRecord, forward bool, dispatch store.Dispatcher) error {
+	if record.Name != c.Name || len(record.Done) > len(c.Steps) {
+		return fmt.Errorf("%w: %s %s", ErrTxMismatch, record.ID, record.Name)
+	}
+	for idx, name := range record.Done {
+		if c.Steps[idx].Name != name {
+			return fmt.Errorf("%w: %s step %s", ErrTxMismatch, record.ID, name)
+		}
+	}
+
+	switch record.Status {
+	case TxRunning:
+		if forward {
+			return c.run(ctx, record.ID, dispatch, record.Done)
+		}
+		return c.compensate(record.ID, dispatch, record.Done, nil, &TxError{
+			ID:  record.ID,
+			Err: context.Canceled,
+		})
+	case TxCompensating:
+		return c.compensate(record.ID, dispatch, record.Done, record.Compensated, &TxError{
+			ID:   record.ID,
+			Step: record.Step,
+			Err:  errors.New(record.Err),
+		})
+	}
+	return nil
+}
+
+// run does the steps after done
+func (c *Transaction) run(ctx context.Context, id string, dispatch store.Dispatcher, done []string) error {
+	done = append([]string(nil), done...)
+	for _, step := range c.Steps[len(done):] {
+		err := ctx.Err()
+		if err == nil {
+			err = step.Do(ctx, dispatch)
+		}
+		if err != nil {
+			txErr := &TxError{
+				ID:   id,
+				Step: step.Name,
+				Err:  err,
+			}
+			return c.compensate(id, dispatch, done, nil, txErr)
+		}
+		dispatch.Dispatch(&TxStepAction{ID: id, Step: step.Name})
+		done = append(done, step.Name)
+	}
+
+	dispatch.Dispatch(&TxEndAction{ID: id, Status: TxCommitted})
+	return nil
+}
+
+// compensate dispatches the compensations of the steps done in reverse order, except compensated
+func (c *Transaction) compensate(id string, dispatch store.Dispatcher, done []string, compensated []string, err *TxError) error {
+	dispatch.Dispatch(&TxCompensateAction{ID: id, Step: err.Step, Err: err.Err.Error()})
+	for idx := len(done) - 1 - len(compensated); idx >= 0; idx-- {
+		step := c.Steps[idx]
+		if step.Compensation != nil {
+			dispatch.Dispatch(step.Compensation)
+		}
+		dispatch.Dispatch(&TxStepAction{ID: id, Step: step.Name, Compensated: true})
+	}
+	dispatch.Dispatch(&TxEndAction{ID: id, Status: TxCompensated})
+	return err
+}
+
+// TxRecord is the progress of a transaction.
+type TxRecord struct {
+	ID     string
+	Name   string
+	Status TxStatus
+	// Done is the steps done in order
+	Done []string
+	// Compensated is the steps compensated in reverse order
+	Compensated []string
+	// Step is the step failed and Err is its error when compensating
+	Step string
+	Err  string
+}
+
+// TxLog is the transactions recorded, it is a value class.
+type TxLog struct {
+	Records map[string]TxRecord
+}
+
+func (c TxLog) StateInterface() {}
+
+// Pending returns the transactions running or compensating, to be resumed, in order of ID.
+func (c TxLog) Pending() []TxRecord {
+	var pending []TxRecord
+	for _, record := range c.Records {
+		if record.Status == TxRunning || record.Status == TxCompensating {
+			pending = append(pending, record)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+	return pending
+}
+
+// TxBeginAction begins a transaction.
+type TxBeginAction struct {
+	ID   string
+	Name string
+}
+
+// TxStepAction records a step done, or compensated.
+type TxStepAction struct {
+	ID          string
+	Step        string
+	Compensated bool
+}
+
+// TxCompensateAction begins to compensate the transaction failed at the step.
+type TxCompensateAction struct {
+	ID   string
+	Step string
+	Err  string
+}
+
+// TxEndAction ends a transaction committed or compensated.
+type TxEndAction struct {
+	ID     string
+	Status TxStatus
+}
+
+// TxForgetAction removes the record of a transaction ended.
+type TxForgetAction struct {
+	ID string
+}
+
+// ReduceTxLog reduces the actions of transactions, it can be used with store.NewSlice.
+func ReduceTxLog(log TxLog, action store.Action) (TxLog, error) {
+	switch reified := action.(type) {
+	case *TxBeginAction:
+		return log.with(TxRecord{
+			ID:     reified.ID,
+			Name:   reified.Name,
+			Status: TxRunning,
+		}), nil
+	case *TxStepAction:
+		record, ok := log.Records[reified.ID]
+		if !ok {
+			return log, nil
+		}
+		if reified.Compensated {
+			record.Compensated = append(record.Compensated[:len(record.Compensated):len(record.Compensated)], reified.Step)
+		} else {
+			record.Done = append(record.Done[:len(record.Done):len(record.Done)], reified.Step)
+		}
+		return log.with(record), nil
+	case *TxCompensateAction:
+		record, ok := log.Records[reified.ID]
+		if !ok {
+			return log, nil
+		}
+		record.Status = TxCompensating
+		record.Step = reified.Step
+		record.Err = reified.Err
+		return log.with(record), nil
+	case *TxEndAction:
+		record, ok := log.Records[reified.ID]
+		if !ok {
+			return log, nil
+		}
+		record.Status = reified.Status
+		return log.with(record), nil
+	case *TxForgetAction:
+		if _, ok := log.Records[reified.ID]; !ok {
+			return log, nil
+		}
+		return log.without(reified.ID), nil
+	}
+	return log, nil
+}
+
+// with returns a new log with the record
+func (c TxLog) with(record TxRecord) TxLog {
+	records := make(map[string]TxRecord, len(c.Records)+1)
+	for key, value := range c.Records {
+		records[key] = value
+	}
+	records[record.ID] = record
+	return TxLog{Records: records}
+}
+
+// without returns a new log without the record
+func (c TxLog) without(id string) TxLog {
+	records := make(map[string]TxRecord, len(c.Records))
+	for key, value := range c.Records {
+		if key != id {
+			records[key] = value
+		}
+	}
+	return TxLog{Records: records}
+}
@@ -0,0 +1,269 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rookiecj/go-store/store"
+)
+
+type orderState struct {
+	reserved int
+	charged  int
+	txs      TxLog
+}
+
+func (c orderState) StateInterface() {}
+
+type stockState struct {
+	reserved int
+	charged  int
+}
+
+func (c stockState) StateInterface() {}
+
+type reserveAction struct{ count int }
+
+type chargeAction struct{ count int }
+
+func stockReducer(state stockState, action store.Action) (stockState, error) {
+	switch reified := action.(type) {
+	case *reserveAction:
+		state.reserved += reified.count
+	case *chargeAction:
+		state.charged += reified.count
+	}
+	return state, nil
+}
+
+func newOrderStore() store.Store[orderState] {
+	stock := store.NewSlice("stock", store.Lens[orderState, stockState]{
+		Get: func(state orderState) stockState {
+			return stockState{reserved: state.reserved, charged: state.charged}
+		},
+		Set: func(state orderState, value stockState) orderState {
+			state.reserved, state.charged = value.reserved, value.charged
+			return state
+		},
+	}, stockReducer)
+	txs := store.NewSlice("txs", store.Lens[orderState, TxLog]{
+		Get: func(state orderState) TxLog {
+			return state.txs
+		},
+		Set: func(state orderState, value TxLog) orderState {
+			state.txs = value
+			return state
+		},
+	}, ReduceTxLog)
+	return store.NewStore(orderState{}, store.CombineSlices[orderState](stock, txs))
+}
+
+var errCharge = errors.New("charge fails")
+
+func newOrderTx(chargeErr error) *Transaction {
+	return &Transaction{
+		Name: "order",
+		Steps: []Step{
+			{
+				Name: "reserve",
+				Do: func(ctx context.Context, dispatch store.Dispatcher) error {
+					dispatch.Dispatch(&reserveAction{1})
+					return nil
+				},
+				Compensation: &reserveAction{-1},
+			},
+			{
+				Name: "charge",
+				Do: func(ctx context.Context, dispatch store.Dispatcher) error {
+					if chargeErr != nil {
+						return chargeErr
+					}
+					dispatch.Dispatch(&chargeAction{1})
+					return nil
+				},
+				Compensation: &chargeAction{-1},
+			},
+			{
+				Name: "ship",
+				Do: func(ctx context.Context, dispatch store.Dispatcher) error {
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func Test_Transaction(t *testing.T) {
+	tests := []struct {
+		name string
+		// record to resume, run if nil
+		record  *TxRecord
+		forward bool
+		// error of the charge step
+		chargeErr error
+		// state before run
+		before   orderState
+		wantErr  error
+		want     TxRecord
+		reserved int
+		charged  int
+	}{
+		{
+			name: "committed",
+			want: TxRecord{
+				ID: "1", Name: "order", Status: TxCommitted,
+				Done: []string{"reserve", "charge", "ship"},
+			},
+			reserved: 1,
+			charged:  1,
+		},
+		{
+			name:      "compensated",
+			chargeErr: errCharge,
+			wantErr:   errCharge,
+			want: TxRecord{
+				ID: "1", Name: "order", Status: TxCompensated,
+				Done:        []string{"reserve"},
+				Compensated: []string{"reserve"},
+				Step:        "charge", Err: errCharge.Error(),
+			},
+		},
+		{
+			name:    "resume forward",
+			record:  &TxRecord{ID: "1", Name: "order", Status: TxRunning, Done: []string{"reserve"}},
+			forward: true,
+			before:  orderState{reserved: 1},
+			want: TxRecord{
+				ID: "1", Name: "order", Status: TxCommitted,
+				Done: []string{"reserve", "charge", "ship"},
+			},
+			reserved: 1,
+			charged:  1,
+		},
+		{
+			name:    "resume to compensate",
+			record:  &TxRecord{ID: "1", Name: "order", Status: TxRunning, Done: []string{"reserve", "charge"}},
+			before:  orderState{reserved: 1, charged: 1},
+			wantErr: context.Canceled,
+			want: TxRecord{
+				ID: "1", Name: "order", Status: TxCompensated,
+				Done:        []string{"reserve", "charge"},
+				Compensated: []string{"charge", "reserve"},
+				Err:         context.Canceled.Error(),
+			},
+		},
+		{
+			name: "resume compensating",
+			record: &TxRecord{
+				ID: "1", Name: "order", Status: TxCompensating,
+				Done:        []string{"reserve", "charge"},
+				Compensated: []string{"charge"},
+				Step:        "ship", Err: "ship fails",
+			},
+			before:  orderState{reserved: 1},
+			wantErr: &TxError{},
+			want: TxRecord{
+				ID: "1", Name: "order", Status: TxCompensated,
+				Done:        []string{"reserve", "charge"},
+				Compensated: []string{"charge", "reserve"},
+				Step:        "ship", Err: "ship fails",
+			},
+		},
+		{
+			name:    "mismatch",
+			record:  &TxRecord{ID: "1", Name: "order", Status: TxRunning, Done: []string{"charge"}},
+			wantErr: ErrTxMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			st := newOrderStore()
+			before := tt.before
+			if tt.record != nil {
+				before.txs = TxLog{}.with(*tt.record)
+			}
+			st.Dispatch(store.NewResetAction(before))
+
+			tx := newOrderTx(tt.chargeErr)
+			var err error
+			if tt.record != nil {
+				err = tx.Resume(context.Background(), *tt.record, tt.forward, st)
+			} else {
+				err = tx.Run(context.Background(), "1", st)
+			}
+			st.Stop()
+			st.WaitForStore()
+
+			var txErr *TxError
+			if errors.As(tt.wantErr, &txErr) {
+				if !errors.As(err, &txErr) {
+					t.Errorf("Run: want TxError got %v", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run: want error %v got %v", tt.wantErr, err)
+			}
+			if errors.Is(tt.wantErr, ErrTxMismatch) {
+				return
+			}
+
+			state := st.GetState()
+			if got := state.txs.Records["1"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TxRecord: want %+v got %+v", tt.want, got)
+			}
+			if len(state.txs.Pending()) != 0 {
+				t.Errorf("Pending: want none got %v", state.txs.Pending())
+			}
+			if state.reserved != tt.reserved || state.charged != tt.charged {
+				t.Errorf("state: want %d %d got %d %d", tt.reserved, tt.charged, state.reserved, state.charged)
+			}
+		})
+	}
+
+	t.Run("as a thunk", func(t *testing.T) {
+		st := newOrderStore()
+
+		task := RunTransaction(context.Background(), st, newOrderTx(errCharge), "1")
+		if err := waitTask(t, task); !errors.Is(err, errCharge) {
+			t.Errorf("Wait: want %v got %v", errCharge, err)
+		}
+		st.Stop()
+		st.WaitForStore()
+
+		if got := st.GetState().txs.Records["1"].Status; got != TxCompensated {
+			t.Errorf("Status: want %v got %v", TxCompensated, got)
+		}
+	})
+
+	t.Run("interrupted by Stop", func(t *testing.T) {
+		st := newOrderStore()
+
+		running := make(chan struct{})
+		tx := newOrderTx(nil)
+		tx.Steps[1].Do = func(ctx context.Context, dispatch store.Dispatcher) error {
+			close(running)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		task := RunTransaction(context.Background(), st, tx, "1")
+		<-running
+		st.Stop()
+		st.WaitForStore()
+
+		select {
+		case <-task.Done():
+		default:
+			t.Fatalf("WaitForStore: returned before the transaction")
+		}
+		if err := waitTask(t, task); !errors.Is(err, ErrTxInterrupted) {
+			t.Errorf("Wait: want %v got %v", ErrTxInterrupted, err)
+		}
+		// to Resume
+		if pending := st.GetState().txs.Pending(); len(pending) != 1 || len(pending[0].Done) != 1 {
+			t.Errorf("Pending: want reserve done got %v", pending)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package sched
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/rookiecj/go-store/logger"
+)
+
+// PanicHandler handles a panic recovered from a task with the stack trace.
+type PanicHandler func(recovered any, stack []byte)
+
+var panicHandler atomic.Pointer[PanicHandler]
+
+// SetPanicHandler sets the handler for panics in tasks of all schedulers,
+// the default handler logs the panic with the stack trace.
+func SetPanicHandler(handler PanicHandler) {
+	if handler == nil {
+		panicHandler.Store(nil)
+		return
+	}
+	panicHandler.Store(&handler)
+}
+
+func logPanic(recovered any, stack []byte) {
+	logger.LogForcedf("sched: panic in task: %v\n%s", recovered, stack)
+}
+
+// runTask runs a task, a panic is recovered not to stop the scheduler
+func runTask(task TaskFunc) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			stack := debug.Stack()
+			if handler := panicHandler.Load(); handler != nil {
+				(*handler)(recovered, stack)
+				return
+			}
+			logPanic(recovered, stack)
+		}
+	}()
+	task()
+}
@@ -0,0 +1,54 @@
+package sched
+
+import (
+	"testing"
+)
+
+func Test_Scheduler_Panic(t *testing.T) {
+
+	tests := []struct {
+		name string
+		s    Scheduler
+	}{
+		{
+			name: "main",
+			s:    NewMainScheduler(),
+		},
+		{
+			name: "background",
+			s:    newBackgroundScheduler(),
+		},
+		{
+			name: "immediate",
+			s:    newImmScheduler(),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			recovered := make(chan any, 1)
+			SetPanicHandler(func(value any, stack []byte) {
+				recovered <- value
+			})
+			defer SetPanicHandler(nil)
+
+			tt.s.Schedule(func() {
+				panic("task panics")
+			})
+			if got := <-recovered; got != "task panics" {
+				t.Errorf("Panic: got %v", got)
+			}
+
+			// still runs tasks
+			done := make(chan struct{})
+			tt.s.Schedule(func() {
+				close(done)
+			})
+			<-done
+
+			tt.s.Stop()
+			tt.s.WaitForScheduler()
+		})
+	}
+}
@@ -56,7 +56,7 @@ func (c *backgroundScheduler) Schedule(task TaskFunc) error {
 
 	go func() {
 		logger.Debugf("BG: Schedule: run task\n")
-		task()
+		runTask(task)
 
 		c.lock.Lock()
 		c.taskCount--
@@ -11,7 +11,7 @@ func (c *immediateScheduler) start() {}
 func (c *immediateScheduler) Stop() {}
 
 func (c *immediateScheduler) Schedule(task TaskFunc) error {
-	task()
+	runTask(task)
 	return nil
 }
 
@@ -7,7 +7,13 @@ import (
 	"sync/atomic"
 )
 
-var ErrNotStarted = errors.New("scheduler not started")
+var (
+	ErrNotStarted = errors.New("scheduler not started")
+	// ErrStopped is returned when a task is scheduled after Stop
+	ErrStopped = errors.New("scheduler stopped")
+	// ErrQueueFull is returned when the tasks waiting reach the capacity
+	ErrQueueFull = errors.New("queue full")
+)
 
 type mainScheduler struct {
 	taskCount  atomic.Int64
@@ -16,15 +22,28 @@ type mainScheduler struct {
 	idleLock   *sync.Mutex
 	idleSignal *sync.Cond
 	doneWG     sync.WaitGroup
+
+	// scheduleLock guards stopped and capacity check
+	scheduleLock sync.Mutex
+	stopped      bool
+	// capacity of taskQ, no limit if 0
+	capacity int
 }
 
 func NewMainScheduler() Scheduler {
+	return NewMainSchedulerWithCapacity(0)
+}
+
+// NewMainSchedulerWithCapacity creates a main scheduler which has tasks up to capacity waiting,
+// Schedule returns ErrQueueFull if full. no limit if capacity is 0
+func NewMainSchedulerWithCapacity(capacity int) Scheduler {
 	idleLock := &sync.Mutex{}
 	scheduler := &mainScheduler{
 		taskCount:  atomic.Int64{},
 		idleLock:   idleLock,
 		idleSignal: sync.NewCond(idleLock),
 		doneWG:     sync.WaitGroup{},
+		capacity:   capacity,
 	}
 	scheduler.start()
 	return scheduler
@@ -54,7 +73,7 @@ func (c *mainScheduler) start() {
 			//logger.Debugf("mainScheduler: task pop: %d\n", c.taskQ.Len())
 			if task, err := c.taskQ.Pop(); err == nil {
 				//logger.Debugf("mainScheduler: task run remains:%d\n", c.taskQ.Len())
-				task()
+				runTask(task)
 			}
 
 			c.idleLock.Lock()
@@ -85,8 +104,16 @@ func (c *mainScheduler) Stop() {
 	c.stop()
 }
 
+// stop schedules the stop task after the tasks scheduled so far
 func (c *mainScheduler) stop() {
-	c.Schedule(func() {
+	c.scheduleLock.Lock()
+	defer c.scheduleLock.Unlock()
+	if c.stopped || c.taskQ == nil {
+		return
+	}
+	c.stopped = true
+
+	c.taskQ.Push(func() {
 		logger.Debugf("mainScheduler: stop task\n")
 		c.taskCount.Add(-1)
 	})
@@ -123,6 +150,15 @@ func (c *mainScheduler) Schedule(task TaskFunc) error {
 		return ErrNotStarted
 	}
 
+	c.scheduleLock.Lock()
+	defer c.scheduleLock.Unlock()
+	if c.stopped {
+		return ErrStopped
+	}
+	if c.capacity > 0 && c.taskQ.Len() >= c.capacity {
+		return ErrQueueFull
+	}
+
 	c.taskQ.Push(task)
 	return nil
 }
@@ -77,3 +77,38 @@ func Test_mainScheduler_Schedule(t *testing.T) {
 		})
 	}
 }
+
+func Test_mainScheduler_Schedule_Errors(t *testing.T) {
+
+	t.Run("stopped", func(t *testing.T) {
+		c := NewMainScheduler()
+		c.Stop()
+		if err := c.Schedule(func() {}); err != ErrStopped {
+			t.Errorf("Schedule after Stop want %v got %v", ErrStopped, err)
+		}
+		c.WaitForScheduler()
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		c := NewMainSchedulerWithCapacity(1)
+
+		blocked := make(chan struct{})
+		running := make(chan struct{})
+		c.Schedule(func() {
+			close(running)
+			<-blocked
+		})
+		<-running
+
+		if err := c.Schedule(func() {}); err != nil {
+			t.Errorf("Schedule want nil got %v", err)
+		}
+		if err := c.Schedule(func() {}); err != ErrQueueFull {
+			t.Errorf("Schedule when full want %v got %v", ErrQueueFull, err)
+		}
+		close(blocked)
+
+		c.Stop()
+		c.WaitForScheduler()
+	})
+}
@@ -8,6 +8,7 @@ type Scheduler interface {
 	start()
 
 	// Schedule schedules a task
+	// it returns an error if the task is not going to run, like ErrStopped or ErrQueueFull
 	Schedule(task TaskFunc) error
 
 	// idle -> close model
@@ -5,6 +5,7 @@ type Action any
 
 // AsyncAction provides a way to dispatch actions asynchronously.
 // It is called with Dispatcher which can be used to dispatch actions.
+// it runs in the dispatch context, use RunThunk for long work.
 type AsyncAction func(Dispatcher)
 
 // Dispatcher dispatches an action.
@@ -15,12 +16,43 @@ type Dispatcher interface {
 }
 
 // InitAction is dispatched when to initialise the store or a subscriber subscribes
+// reducers get InitAction once when the store is created, so that they can initialise the state lazily.
 type InitAction struct{}
 
+// BatchAction reduces its actions in order as one action, the age is increased once.
+// reducers get each action, middlewares and subscribers get the BatchAction.
+// the whole batch is rolled back when an action fails with DiscardOnError or StopOnError.
+type BatchAction struct {
+	Actions []Action
+
+	// reduced is the state reduced by Transaction from the age
+	reduced any
+	from    int64
+}
+
 // 상태에 변화를 주지않는 action
 type UnitAction struct{}
 
 // 상태를 Init 상태로 되돌리는 action
+// the store replaces the state without reducers and notifies subscribers.
+// the zero value resets to the initial state of the store.
 type ResetAction[S State] struct {
-	state S
+	state    S
+	hasState bool
+}
+
+// NewResetAction creates ResetAction which replaces the state with the given state.
+func NewResetAction[S State](state S) *ResetAction[S] {
+	return &ResetAction[S]{
+		state:    state,
+		hasState: true,
+	}
+}
+
+// stateOr returns the state to reset to
+func (c *ResetAction[S]) stateOr(initialState S) S {
+	if c == nil || !c.hasState {
+		return initialState
+	}
+	return c.state
 }
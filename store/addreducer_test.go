@@ -123,7 +123,7 @@ func Test_baseStore_AddReducer(t *testing.T) {
 			tt.b.Stop()
 			tt.b.WaitForStore()
 
-			got := tt.b.getState()
+			got := tt.b.GetState()
 			if !reflect.DeepEqual(tt.want, got.value) {
 				t.Errorf("AddReducer(): got %v, want %v", got, tt.want)
 			}
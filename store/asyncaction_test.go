@@ -141,7 +141,7 @@ func Test_AsyncAction_Run(t *testing.T) {
 				t.Errorf("AsyncAction_Run: done action call want %d times but %d", tt.want, setActionCalled)
 			}
 
-			gotState := tt.b.getState()
+			gotState := tt.b.GetState()
 			if tt.wantState != gotState.value {
 				t.Errorf("AsyncAction_Run: state want %s got %s", tt.wantState, gotState.value)
 			}
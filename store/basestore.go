@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -11,31 +12,162 @@ import (
 
 type baseStore[S State] struct {
 	state S
+	// initialState is for ResetAction
+	initialState S
 	// reducer is called in dispatcher context
 	reducers    []Reducer[S]
 	subscribers []*subscriberEntry[S]
+	// middlewares are called in dispatcher context before reducers
+	middlewares []Middleware[S]
+	// dispatchChain is the middlewares composed with reducing
+	dispatchChain DispatchFunc
 
 	// reduce and dispatch context
 	dispatchScheduler sched.Scheduler
 	age               int64
 	dispatchLock      *sync.Mutex
 	stopWg            *sync.WaitGroup
+	// subscribersLock guards subscribers, which is replaced not modified
+	subscribersLock sync.Mutex
+
+	// published is the state and the age for other goroutines
+	published atomic.Pointer[stateSnapshot[S]]
+	// ctx is cancelled when stopping, for thunks
+	ctx    context.Context
+	cancel context.CancelFunc
+	// thunks running
+	thunks sync.WaitGroup
+
+	// envelope of the action in the dispatch context
+	envelope atomic.Pointer[Envelope]
+
+	// statusLock orders accepting actions and stopping
+	statusLock sync.RWMutex
+	// scheduling is the tasks accepted and not scheduled yet
+	scheduling sync.WaitGroup
+	status     atomic.Int32
+	done       chan struct{}
+
+	// errorPolicy is used in dispatcher context
+	errorPolicy ErrorPolicy
+	errors      *errorReporter
+	// halted by StopOnError, the rest actions are not reduced
+	halted bool
+	// reduceErr is the error of the last action reduced
+	reduceErr error
+	// optimistic updates pending
+	optimistic optimisticLog[S]
+	// mutationCheck reports reducers and subscribers mutating the states
+	mutationCheck atomic.Bool
+
+	// panicHandler and subscriberPanicLimit are used in subscribers context
+	panicHandler         atomic.Pointer[func(err *PanicError)]
+	subscriberPanicLimit atomic.Int32
+}
+
+// stateSnapshot is published after reducing
+type stateSnapshot[S State] struct {
+	state S
+	age   int64
+	// err of reducing, not published
+	err error
 }
 
 type subscriberEntry[S State] struct {
-	scheduler  sched.Scheduler
-	subscriber Subscriber[S]
+	scheduler sched.Scheduler
+	// subscriber gets the change with the age
+	subscriber func(change Change[S])
+	// panics recovered from the subscriber
+	panics atomic.Int32
+
+	// lock guards disposed and running
+	lock sync.Mutex
+	idle *sync.Cond
+	// disposed entry is not notified any more
+	disposed bool
+	// running notifications
+	running int
+	// done is closed when disposed
+	done chan struct{}
+}
+
+func newSubscriberEntry[S State](scheduler sched.Scheduler, subscriber func(change Change[S])) *subscriberEntry[S] {
+	entry := &subscriberEntry[S]{
+		scheduler:  scheduler,
+		subscriber: subscriber,
+		done:       make(chan struct{}),
+	}
+	entry.idle = sync.NewCond(&entry.lock)
+	return entry
+}
+
+// dispose returns false if disposed already
+func (c *subscriberEntry[S]) dispose() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.disposed {
+		return false
+	}
+	c.disposed = true
+	close(c.done)
+	return true
+}
+
+func (c *subscriberEntry[S]) isDisposed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.disposed
+}
+
+// begin returns false if disposed, otherwise end should be called after notifying
+func (c *subscriberEntry[S]) begin() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.disposed {
+		return false
+	}
+	c.running++
+	return true
+}
+
+func (c *subscriberEntry[S]) end() {
+	c.lock.Lock()
+	c.running--
+	c.idle.Broadcast()
+	c.lock.Unlock()
+}
+
+// waitForIdle waits for the notifications running
+func (c *subscriberEntry[S]) waitForIdle() {
+	c.lock.Lock()
+	for c.running > 0 {
+		c.idle.Wait()
+	}
+	c.lock.Unlock()
 }
 
 type baseDisposer struct {
+	once    sync.Once
 	dispose func()
+	// wait waits for the disposed to be done, optional
+	wait func()
 }
 
 func (b *baseDisposer) Dispose() {
 	if b == nil {
 		return
 	}
-	b.dispose()
+	b.once.Do(b.dispose)
+}
+
+func (b *baseDisposer) DisposeAndWait() {
+	if b == nil {
+		return
+	}
+	b.Dispose()
+	if b.wait != nil {
+		b.wait()
+	}
 }
 
 // NewStore creates a store with a reducer and an initial state.
@@ -46,14 +178,26 @@ func NewStore[S State](initialState S, reducer Reducer[S]) Store[S] {
 // NewStoreOn Scheduler should ensure actions to be reduced in order
 // scheduler should be started/stopped properly before/after using Store
 func NewStoreOn[S State](scheduler sched.Scheduler, initialState S, reducer Reducer[S]) Store[S] {
-	return &baseStore[S]{
+	store := &baseStore[S]{
 		state:             initialState,
+		initialState:      initialState,
 		reducers:          []Reducer[S]{reducer},
+		middlewares:       []Middleware[S]{AsyncActionMiddleware[S]()},
 		dispatchScheduler: scheduler,
 		age:               0,
 		dispatchLock:      &sync.Mutex{},
 		stopWg:            &sync.WaitGroup{},
+		done:              make(chan struct{}),
+		errors:            newErrorReporter(),
 	}
+	store.ctx, store.cancel = context.WithCancel(context.Background())
+	store.subscriberPanicLimit.Store(defaultSubscriberPanicLimit)
+	store.composeMiddlewares()
+	store.publish(initialState, 0)
+
+	// let reducers initialise the state
+	store.Dispatch(&InitAction{})
+	return store
 }
 
 func (b *baseStore[S]) AddReducer(reducer Reducer[S]) Store[S] {
@@ -61,41 +205,280 @@ func (b *baseStore[S]) AddReducer(reducer Reducer[S]) Store[S] {
 		return b
 	}
 
-	b.reducers = append(b.reducers, reducer)
+	// reducers are used in dispatcher context
+	if err := b.dispatchScheduler.Schedule(func() {
+		b.reducers = append(b.reducers, reducer)
+	}); err != nil {
+		logger.Errf("Store: AddReducer: %s\n", err)
+	}
+
+	return b
+}
 
+func (b *baseStore[S]) SetErrorPolicy(policy ErrorPolicy) Store[S] {
+	if b == nil {
+		return b
+	}
+
+	// the actions dispatched before are reduced with the previous policy
+	if err := b.dispatchScheduler.Schedule(func() {
+		b.errorPolicy = policy
+	}); err != nil {
+		logger.Errf("Store: SetErrorPolicy: %s\n", err)
+	}
+
+	return b
+}
+
+func (b *baseStore[S]) OnError(handler func(err *ReducerError)) Disposer {
+	if b == nil {
+		return nil
+	}
+	return b.errors.onError(handler)
+}
+
+func (b *baseStore[S]) Errors() <-chan *ReducerError {
+	if b == nil {
+		return nil
+	}
+	return b.errors.errors
+}
+
+func (b *baseStore[S]) SetPanicHandler(handler func(err *PanicError)) Store[S] {
+	if b == nil {
+		return b
+	}
+	if handler == nil {
+		b.panicHandler.Store(nil)
+	} else {
+		b.panicHandler.Store(&handler)
+	}
+	return b
+}
+
+func (b *baseStore[S]) SetSubscriberPanicLimit(limit int) Store[S] {
+	if b == nil {
+		return b
+	}
+	b.subscriberPanicLimit.Store(int32(limit))
+	return b
+}
+
+func (b *baseStore[S]) SetMutationCheck(enabled bool) Store[S] {
+	if b == nil {
+		return b
+	}
+	b.mutationCheck.Store(enabled)
 	return b
 }
 
+// reportPanic delivers the panic to the panic handler
+func (b *baseStore[S]) reportPanic(err *PanicError) {
+	if handler := b.panicHandler.Load(); handler != nil {
+		(*handler)(err)
+		return
+	}
+	logPanic(err)
+}
+
 func (b *baseStore[S]) Dispatch(action Action) {
 	if b == nil {
 		return
 	}
 
+	if err := b.TryDispatch(action); err != nil {
+		logger.Errf("Store: dispatch %T: %s\n", action, err)
+	}
+}
+
+func (b *baseStore[S]) DispatchBatch(actions ...Action) {
+	if b == nil || len(actions) == 0 {
+		return
+	}
+
+	b.Dispatch(&BatchAction{Actions: actions})
+}
+
+func (b *baseStore[S]) TryDispatch(action Action) error {
+	if b == nil {
+		return ErrStoreStopped
+	}
+
 	// reduce state in dispatcher context
-	b.dispatchOn(b.dispatchScheduler, action)
+	return b.dispatchOn(b.dispatchScheduler, wrapAction(action, nil))
+}
+
+func (b *baseStore[S]) DispatchSync(ctx context.Context, action Action) (state S, age int64, err error) {
+	if b == nil {
+		err = ErrStoreStopped
+		return
+	}
+
+	envelope := wrapAction(action, nil)
+	result := make(chan stateSnapshot[S], 1)
+	err = b.scheduleAction(b.dispatchScheduler, func() {
+		if ctx.Err() != nil {
+			// the caller has gone
+			return
+		}
+		// subscribers are notified when returned
+		b.runChain(envelope)
+		result <- stateSnapshot[S]{
+			state: b.state,
+			age:   atomic.LoadInt64(&b.age),
+			err:   b.reduceErr,
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	select {
+	case reduced := <-result:
+		return reduced.state, reduced.age, reduced.err
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
 }
 
 // dispatchOn dispatches an action to the store on the scheduler.
-func (b *baseStore[S]) dispatchOn(scheduler sched.Scheduler, action Action) {
+func (b *baseStore[S]) dispatchOn(scheduler sched.Scheduler, envelope *Envelope) error {
 	if b == nil {
+		return ErrStoreStopped
+	}
+
+	return b.scheduleAction(scheduler, func() {
+		// middlewares, then reduce
+		b.runChain(envelope)
+	})
+}
+
+// runChain passes the action through the middlewares to reducers
+func (b *baseStore[S]) runChain(envelope *Envelope) {
+	b.runNext(envelope, b.dispatchChain, envelope.Action)
+}
+
+// runNext passes the action to next, the rest of the chain, in the envelope. a panic in a middleware is recovered
+func (b *baseStore[S]) runNext(envelope *Envelope, next DispatchFunc, action Action) {
+	b.envelope.Store(envelope)
+	b.reduceErr = nil
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err := newPanicError(PanicInMiddleware, action, recovered)
+			b.reduceErr = err
+			b.reportPanic(err)
+		}
+	}()
+	next(action)
+}
+
+// scheduleAction schedules a task for an action while the store is running
+func (b *baseStore[S]) scheduleAction(scheduler sched.Scheduler, task sched.TaskFunc) error {
+	if !b.accept(nil) {
+		return ErrStoreStopped
+	}
+	return b.scheduleAccepted(scheduler, task)
+}
+
+// accept counts a task to be scheduled while the store is running, added is called with counting.
+// Stop waits for the tasks accepted to be scheduled, not to schedule after stopping.
+func (b *baseStore[S]) accept(added func()) bool {
+	b.statusLock.RLock()
+	defer b.statusLock.RUnlock()
+	if Status(b.status.Load()) != StatusRunning {
+		return false
+	}
+	b.scheduling.Add(1)
+	if added != nil {
+		added()
+	}
+	return true
+}
+
+// scheduleAccepted schedules the task accepted without statusLock,
+// the task can run in Schedule like sched.Immediate and call Stop
+func (b *baseStore[S]) scheduleAccepted(scheduler sched.Scheduler, task sched.TaskFunc) error {
+	var once sync.Once
+	scheduled := func() {
+		once.Do(b.scheduling.Done)
+	}
+	defer scheduled()
+
+	return scheduler.Schedule(func() {
+		// running already, not to be waited by Stop in the task
+		scheduled()
+		task()
+	})
+}
+
+// reduceAndDispatch is the last stage of the middleware chain
+func (b *baseStore[S]) reduceAndDispatch(action Action) {
+	if b.halted {
+		logger.Errf("Store: halted, action %T is not reduced\n", action)
+		b.reduceErr = ErrStoreStopped
 		return
 	}
-	switch reified := action.(type) {
-	case AsyncAction:
-		scheduler.Schedule(func() {
-			reified(b)
-		})
-	default:
-		scheduler.Schedule(func() {
-			// reduce
-			oldState := b.getState()
-			//logger.Debugf("Store: reduce: action:%v\n", action)
-			b.state = b.reduce(oldState, action)
-			// dispatch
-			//logger.Debugf("Store: dispatch: action:%v, state: %v\n", action, b.state)
-			b.dispatch(oldState, action, b.state)
-		})
+
+	// reduce
+	oldState := b.state
+	age := atomic.LoadInt64(&b.age) + 1
+	//logger.Debugf("Store: reduce: action:%v\n", action)
+	newState, err := b.reduceAction(oldState, action, age)
+	if err != nil {
+		b.reduceErr = err
+		if b.errorPolicy != ContinueOnError {
+			// roll back
+			if b.errorPolicy == StopOnError {
+				b.halted = true
+				b.Stop()
+			}
+			return
+		}
 	}
+	b.state = newState
+	atomic.StoreInt64(&b.age, age)
+	b.publish(b.state, age)
+	b.recordOptimistic(oldState, action, b.state)
+	// dispatch
+	//logger.Debugf("Store: dispatch: action:%v, state: %v\n", action, b.state)
+	b.dispatch(age, oldState, action, b.state)
+}
+
+// publish makes the state visible to other goroutines
+func (b *baseStore[S]) publish(state S, age int64) {
+	b.published.Store(&stateSnapshot[S]{
+		state: state,
+		age:   age,
+	})
+}
+
+func (b *baseStore[S]) applyMiddleware(middlewares ...Middleware[S]) {
+	if b == nil {
+		return
+	}
+
+	// actions dispatched before are passed through the previous chain
+	if err := b.dispatchScheduler.Schedule(func() {
+		b.middlewares = append(b.middlewares, middlewares...)
+		b.composeMiddlewares()
+	}); err != nil {
+		logger.Errf("Store: ApplyMiddleware: %s\n", err)
+	}
+}
+
+// composeMiddlewares builds dispatchChain, the first middleware is called first
+func (b *baseStore[S]) composeMiddlewares() {
+	next := DispatchFunc(b.reduceAndDispatch)
+	for idx := len(b.middlewares) - 1; idx >= 0; idx-- {
+		middleware := b.middlewares[idx]
+		inner := next
+		next = func(action Action) {
+			// the cause is kept for the actions dispatched later by the api
+			middleware(&middlewareAPI[S]{store: b, cause: b.envelope.Load(), next: inner}, inner, action)
+		}
+	}
+	b.dispatchChain = next
 }
 
 func (b *baseStore[S]) Subscribe(subscriber Subscriber[S]) Disposer {
@@ -111,124 +494,372 @@ func (b *baseStore[S]) SubscribeOn(scheduler sched.Scheduler, subscriber Subscri
 		return nil
 	}
 
-	if len(b.subscribers) == 0 {
-		b.onFirstSubscribe()
+	if scheduler == nil {
+		scheduler = b.dispatchScheduler
+	}
+
+	_, disposer := b.subscribeChange(scheduler, func(change Change[S]) {
+		subscriber(change.NewState, change.OldState, change.Action)
+	})
+	return disposer
+}
+
+func (b *baseStore[S]) SubscribeContext(ctx context.Context, scheduler sched.Scheduler, subscriber Subscriber[S]) Disposer {
+	if b == nil {
+		return nil
 	}
 
 	if scheduler == nil {
 		scheduler = b.dispatchScheduler
 	}
 
-	entry := subscriberEntry[S]{
-		scheduler:  scheduler,
-		subscriber: subscriber}
+	entry, disposer := b.subscribeChange(scheduler, func(change Change[S]) {
+		subscriber(change.NewState, change.OldState, change.Action)
+	})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			disposer.Dispose()
+		case <-entry.done:
+		case <-b.done:
+			// nothing to be notified
+		}
+	}()
+	return disposer
+}
+
+// subscribeChange adds a subscriber notified with InitAction first
+func (b *baseStore[S]) subscribeChange(scheduler sched.Scheduler, subscriber func(change Change[S])) (*subscriberEntry[S], Disposer) {
+	entry := newSubscriberEntry(scheduler, subscriber)
 
 	// dispatch before adding to subscribers
-	b.dispatchWhenSubscribe(&entry, b.state, b.state, &InitAction{})
+	b.dispatchWhenSubscribe(entry, &InitAction{})
 
-	b.subscribers = append(b.subscribers, &entry)
+	return entry, b.newSubscriberDisposer(entry)
+}
 
+// newSubscriberDisposer can be called in the subscriber, not to lock dispatchLock
+func (b *baseStore[S]) newSubscriberDisposer(entry *subscriberEntry[S]) Disposer {
 	return &baseDisposer{
 		dispose: func() {
-			b.dispatchLock.Lock()
-			for idx := 0; idx < len(b.subscribers); idx++ {
-				if &entry == b.subscribers[idx] {
-					b.subscribers = append(b.subscribers[:idx], b.subscribers[idx+1:]...)
-					break
-				}
+			if entry.dispose() {
+				b.removeSubscriber(entry)
 			}
-			b.dispatchLock.Unlock()
-			// need notify?
 		},
+		wait: entry.waitForIdle,
+	}
+}
+
+// removeSubscriber removes the entry, subscribers is copied not to change the one dispatching
+func (b *baseStore[S]) removeSubscriber(entry *subscriberEntry[S]) {
+	b.subscribersLock.Lock()
+	defer b.subscribersLock.Unlock()
+	for idx := 0; idx < len(b.subscribers); idx++ {
+		if entry == b.subscribers[idx] {
+			subscribers := make([]*subscriberEntry[S], 0, len(b.subscribers)-1)
+			subscribers = append(subscribers, b.subscribers[:idx]...)
+			b.subscribers = append(subscribers, b.subscribers[idx+1:]...)
+			break
+		}
+	}
+}
+
+func (b *baseStore[S]) GetState() (state S) {
+	if b == nil {
+		return
+	}
+	state, _ = b.Snapshot()
+	return
+}
+
+func (b *baseStore[S]) Envelope() *Envelope {
+	if b == nil {
+		return nil
 	}
+	return b.envelope.Load()
 }
 
-func (b *baseStore[S]) getState() (state S) {
+func (b *baseStore[S]) Snapshot() (state S, age int64) {
 	if b == nil {
 		return
 	}
-	return b.state
+	snapshot := b.published.Load()
+	return snapshot.state, snapshot.age
 }
 
 func (b *baseStore[S]) Stop() {
+	if b == nil {
+		return
+	}
+
+	b.statusLock.Lock()
+	if !b.status.CompareAndSwap(int32(StatusRunning), int32(StatusStopping)) {
+		b.statusLock.Unlock()
+		return
+	}
+	b.statusLock.Unlock()
+
+	// stopped after reducing the actions accepted
+	b.scheduling.Wait()
+	if err := b.dispatchScheduler.Schedule(b.stopped); err != nil {
+		b.stopped()
+	}
+	// thunks can not dispatch any more
+	b.cancel()
+
 	if b.dispatchScheduler != sched.Main {
 		b.dispatchScheduler.Stop()
 	}
 }
 
+func (b *baseStore[S]) stopped() {
+	b.status.Store(int32(StatusStopped))
+	b.errors.close()
+	close(b.done)
+}
+
 func (b *baseStore[S]) WaitForStore() {
 	if b == nil {
 		return
 	}
 
-	b.dispatchScheduler.WaitForScheduler()
+	<-b.done
+	b.thunks.Wait()
+	// Main is shared with others, not stopped
+	if b.dispatchScheduler != sched.Main {
+		b.dispatchScheduler.WaitForScheduler()
+	}
+}
+
+func (b *baseStore[S]) Status() Status {
+	if b == nil {
+		return StatusStopped
+	}
+	return Status(b.status.Load())
+}
+
+func (b *baseStore[S]) Done() <-chan struct{} {
+	if b == nil {
+		return nil
+	}
+	return b.done
+}
+
+// reduceAction reduces an action, ResetAction bypasses reducers and BatchAction reduces its actions in order
+func (b *baseStore[S]) reduceAction(state S, action Action, age int64) (S, *ReducerError) {
+	switch reified := action.(type) {
+	case *ResetAction[S]:
+		// bypass reducers
+		return reified.stateOr(b.initialState), nil
+	case ResetAction[S]:
+		return reified.stateOr(b.initialState), nil
+	case *BatchAction:
+		if reified.reduced != nil && reified.from == atomic.LoadInt64(&b.age) {
+			// reduced by Transaction on the same state
+			if reduced, ok := reified.reduced.(S); ok {
+				reified.reduced = nil
+				return reduced, nil
+			}
+		}
+		var firstErr *ReducerError
+		for _, inner := range reified.Actions {
+			var err *ReducerError
+			state, err = b.reduceAction(state, inner, age)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				if b.errorPolicy != ContinueOnError {
+					// the batch is rolled back
+					break
+				}
+			}
+		}
+		return state, firstErr
+	}
+	return b.reduce(state, action, age)
 }
 
 // reduce should be called in the same(Main) context
-func (b *baseStore[S]) reduce(state S, action Action) S {
+// it returns the first error, with ContinueOnError all the errors are reported
+func (b *baseStore[S]) reduce(state S, action Action, age int64) (S, *ReducerError) {
 	if b == nil {
-		return state
+		return state, nil
 	}
 	reducers := b.reducers[:]
-	newState := b.state
+	newState := state
+	var firstErr *ReducerError
 	var err error
-	for _, reducer := range reducers {
-		newState, err = reducer(newState, action)
+	var prints fingerprint
+	if b.mutationCheck.Load() {
+		prints = fingerprintOf(state)
+	}
+	for idx, reducer := range reducers {
+		newState, err = b.callReducer(reducer, newState, action)
+		if prints != nil {
+			// the state given is shared with the old state
+			after := fingerprintOf(state)
+			if mutated := prints.mutated(MutationInReducer, after); mutated != nil {
+				prints = after
+				if err == nil {
+					err = mutated
+				} else {
+					b.reportError(&ReducerError{Action: action, Reducer: idx, Age: age, Err: mutated})
+				}
+			}
+		}
 		if err != nil {
 			if errors.Is(err, ErrSkipReducing) {
 				break
 			}
-			logger.Errf("error reducing: %s\n", err)
+			reducerErr := &ReducerError{
+				Action:  action,
+				Reducer: idx,
+				Age:     age,
+				Err:     err,
+			}
+			b.reportError(reducerErr)
+			if firstErr == nil {
+				firstErr = reducerErr
+			}
+			if b.errorPolicy != ContinueOnError {
+				break
+			}
 		}
 	}
-	return newState
+	return newState, firstErr
+}
+
+// callReducer calls the reducer, a panic is returned as PanicError with the state not changed
+func (b *baseStore[S]) callReducer(reducer Reducer[S], state S, action Action) (newState S, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			newState = state
+			err = newPanicError(PanicInReducer, action, recovered)
+		}
+	}()
+	return reducer(state, action)
+}
+
+// reportError delivers the error to OnError handlers and Errors
+func (b *baseStore[S]) reportError(err *ReducerError) {
+	if b.optimistic.replaying {
+		return
+	}
+	logger.Errf("error reducing: %s\n", err)
+	b.errors.report(err)
 }
 
 // dispatch state to subscribers in their context
-func (b *baseStore[S]) dispatch(oldState S, action Action, newState S) {
+func (b *baseStore[S]) dispatch(age int64, oldState S, action Action, newState S) {
 	if b == nil {
 		return
 	}
 
 	// wait for previous dispatching
 	b.dispatchLock.Lock()
-	if len(b.subscribers) > 0 {
-		clonedSubscribers := b.subscribers[:]
-		age := atomic.AddInt64(&b.age, 1)
-
+	b.subscribersLock.Lock()
+	clonedSubscribers := b.subscribers
+	b.subscribersLock.Unlock()
+	if len(clonedSubscribers) > 0 {
+		change := Change[S]{
+			NewState: newState,
+			OldState: oldState,
+			Action:   action,
+			Age:      age,
+			Envelope: b.envelope.Load(),
+		}
 		// for a subscriber with its own scheduler
 		wg := &sync.WaitGroup{}
 		// dispatch state in subscriber's context
 		for _, entry := range clonedSubscribers {
-			b.doDispatchSubscriberLocked(entry, wg, age, newState, oldState, action)
+			b.doDispatchSubscriberLocked(entry, wg, change)
 		}
 		// wait for subscribers scheduler to done
 		wg.Wait()
+
+		b.evictPanickingSubscribersLocked()
 	}
 	b.dispatchLock.Unlock()
 }
 
-func (b *baseStore[S]) dispatchWhenSubscribe(entry *subscriberEntry[S], newState S, oldState S, action Action) {
+// evictPanickingSubscribersLocked unsubscribes subscribers panicked more than the limit
+func (b *baseStore[S]) evictPanickingSubscribersLocked() {
+	b.subscribersLock.Lock()
+	subscribers := b.subscribers
+	b.subscribersLock.Unlock()
+
+	for _, entry := range subscribers {
+		if b.panickedTooMuch(entry) && entry.dispose() {
+			logger.LogForcedf("Store: unsubscribe the subscriber panicked %d times\n", entry.panics.Load())
+			b.removeSubscriber(entry)
+		}
+	}
+}
+
+func (b *baseStore[S]) panickedTooMuch(entry *subscriberEntry[S]) bool {
+	limit := b.subscriberPanicLimit.Load()
+	return limit > 0 && entry.panics.Load() >= limit
+}
+
+func (b *baseStore[S]) dispatchWhenSubscribe(entry *subscriberEntry[S], action Action) {
 	if b == nil {
 		return
 	}
 
-	b.dispatchLock.Lock()
-	b.dispatchScheduler.Schedule(func() {
+	// in dispatcher context, the subscriber gets the actions reduced after the state
+	err := b.dispatchScheduler.Schedule(func() {
+		b.dispatchLock.Lock()
+		defer b.dispatchLock.Unlock()
+		if entry.isDisposed() {
+			return
+		}
+
+		// the state at the moment in dispatcher context
+		state, age := b.state, atomic.LoadInt64(&b.age)
+		envelope := wrapAction(action, nil)
+		// the envelope of the action notified while notifying
+		last := b.envelope.Swap(envelope)
 		wg := sync.WaitGroup{}
-		b.doDispatchSubscriberLocked(entry, &wg, b.age, newState, oldState, action)
+		b.doDispatchSubscriberLocked(entry, &wg, Change[S]{
+			NewState: state,
+			OldState: state,
+			Action:   action,
+			Age:      age,
+			Envelope: envelope,
+		})
 		wg.Wait()
-	})
-	b.dispatchLock.Unlock()
+		b.envelope.Store(last)
+		if b.panickedTooMuch(entry) {
+			entry.dispose()
+			return
+		}
 
+		b.subscribersLock.Lock()
+		defer b.subscribersLock.Unlock()
+		if entry.isDisposed() {
+			// disposed while notifying
+			return
+		}
+		if len(b.subscribers) == 0 {
+			b.onFirstSubscribe()
+		}
+		subscribers := make([]*subscriberEntry[S], 0, len(b.subscribers)+1)
+		subscribers = append(subscribers, b.subscribers...)
+		b.subscribers = append(subscribers, entry)
+	})
+	if err != nil {
+		logger.Errf("Store: Subscribe: %s\n", err)
+	}
 }
 
-func (b *baseStore[S]) doDispatchSubscriberLocked(entry *subscriberEntry[S], wg *sync.WaitGroup, age int64, newState S, oldState S, action Action) {
+func (b *baseStore[S]) doDispatchSubscriberLocked(entry *subscriberEntry[S], wg *sync.WaitGroup, change Change[S]) {
 
 	// we are in the dispatcher context, so we can call subscriber directly
 	if entry.scheduler == b.dispatchScheduler {
 		//logger.Debugf("Store: doDispatchSubscriberLocked: schedule in same scheduler with action %v\n", action)
-		entry.subscriber(newState, oldState, action)
+		b.notifySubscriber(entry, change)
 		return
 	}
 
@@ -239,17 +870,53 @@ func (b *baseStore[S]) doDispatchSubscriberLocked(entry *subscriberEntry[S], wg
 		}
 
 		//logger.Debugf("Store: doDispatchSubscriberLocked: schedule subscriber with action %v\n", action)
-		entry.scheduler.Schedule(func() {
+		err := entry.scheduler.Schedule(func() {
+			// 'Done' called after calling a subscriber to ensure all subscribers are one same state
+			// wake up Dispatcher even if the subscriber panics
+			if wg != nil {
+				defer wg.Done()
+			}
 
 			// call subscriber
-			entry.subscriber(newState, oldState, action)
-
-			// 'Done' called after calling a subscriber to ensure all subscribers are one same state
-			// wake up Dispatcher
+			b.notifySubscriber(entry, change)
+		})
+		if err != nil {
+			// the subscriber misses the state, not to wait for it
+			logger.Errf("Store: notify subscriber: %s\n", err)
 			if wg != nil {
 				wg.Done()
 			}
-		})
+		}
+	}
+}
+
+// notifySubscriber calls the subscriber unless disposed, a panic is recovered and counted
+func (b *baseStore[S]) notifySubscriber(entry *subscriberEntry[S], change Change[S]) {
+	if !entry.begin() {
+		return
+	}
+	defer entry.end()
+
+	var newPrints, oldPrints fingerprint
+	if b.mutationCheck.Load() {
+		newPrints, oldPrints = fingerprintOf(change.NewState), fingerprintOf(change.OldState)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			entry.panics.Add(1)
+			b.reportPanic(newPanicError(PanicInSubscriber, change.Action, recovered))
+		}
+	}()
+	entry.subscriber(change)
+
+	if newPrints != nil {
+		mutated := oldPrints.mutated(MutationInSubscriber, fingerprintOf(change.OldState))
+		if mutated == nil {
+			mutated = newPrints.mutated(MutationInSubscriber, fingerprintOf(change.NewState))
+		}
+		if mutated != nil {
+			b.reportError(&ReducerError{Action: change.Action, Reducer: -1, Age: change.Age, Err: mutated})
+		}
 	}
 }
 
@@ -83,7 +83,7 @@ func Test_NewStore(t *testing.T) {
 			//if wantRaw.reduceScheduler != Main || gotRaw.reduceScheduler != Main {
 			//	t.Errorf("NewStore() = %v, want %v", got, tt.want)
 			//}
-			if !reflect.DeepEqual(wantRaw.state, gotRaw.state) {
+			if !reflect.DeepEqual(wantRaw.GetState(), gotRaw.GetState()) {
 				t.Errorf("NewStore() = %v, want %v", got, tt.want)
 			}
 
@@ -99,7 +99,7 @@ func Test_NewStore(t *testing.T) {
 	}
 }
 
-func Test_baseStore_getState(t *testing.T) {
+func Test_baseStore_GetState(t *testing.T) {
 	type testCase[S State] struct {
 		name string
 		b    Store[S]
@@ -127,8 +127,8 @@ func Test_baseStore_getState(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			if got := tt.b.getState(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("getState() = %v, want %v", got, tt.want)
+			if got := tt.b.GetState(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetState() = %v, want %v", got, tt.want)
 			}
 
 			tt.b.Stop()
@@ -217,7 +217,7 @@ func Test_baseStore_Dispatch(t *testing.T) {
 			tt.b.WaitForStore()
 
 			want := tt.want
-			got := tt.b.getState()
+			got := tt.b.GetState()
 			if !reflect.DeepEqual(got, want) {
 				t.Errorf("Dispatch: want %v got %v, actions %v", want, got, tt.args.actions)
 			}
@@ -284,7 +284,7 @@ func Test_baseStore_ReduceSerialized(t *testing.T) {
 			tt.b.WaitForStore()
 
 			want := tt.want
-			got := tt.b.getState()
+			got := tt.b.GetState()
 			wantToks := strings.Split(want.value, ",")
 			gotToks := strings.Split(got.value, ",")
 			if len(wantToks) != len(gotToks) {
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_baseStore_DispatchBatch(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		policy  ErrorPolicy
+		actions []Action
+		want    myState
+		// age increased by the batch
+		wantAge int64
+	}{
+		{
+			name:    "reduced at once",
+			actions: []Action{&addAction{"1"}, &addAction{"2"}, &addAction{"3"}},
+			want:    myState{value: "0123"},
+			wantAge: 1,
+		},
+		{
+			name:    "nested and reset",
+			actions: []Action{&addAction{"1"}, NewResetAction(myState{value: "a"}), &BatchAction{Actions: []Action{&addAction{"2"}}}},
+			want:    myState{value: "a2"},
+			wantAge: 1,
+		},
+		{
+			name:    "continue on error",
+			policy:  ContinueOnError,
+			actions: []Action{&addAction{"1"}, &failAction{"x"}, &addAction{"2"}},
+			want:    myState{value: "01x2"},
+			wantAge: 1,
+		},
+		{
+			name:    "discarded on error",
+			policy:  DiscardOnError,
+			actions: []Action{&addAction{"1"}, &failAction{"x"}, &addAction{"2"}},
+			want:    myState{value: "0"},
+			wantAge: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMyStateStore().AddReducer(failingReducer).SetErrorPolicy(tt.policy)
+			_, age, _ := b.DispatchSync(context.Background(), &setAction{"0"})
+
+			var notified atomic.Int32
+			var batch atomic.Pointer[BatchAction]
+			b.Subscribe(func(state myState, old myState, action Action) {
+				if _, ok := action.(*InitAction); ok {
+					return
+				}
+				notified.Add(1)
+				if reified, ok := action.(*BatchAction); ok {
+					batch.Store(reified)
+				}
+			})
+
+			b.DispatchBatch(tt.actions...)
+			b.Stop()
+			b.WaitForStore()
+
+			state, gotAge := b.Snapshot()
+			assertState(t, state, tt.want, nil)
+			if gotAge-age != tt.wantAge {
+				t.Errorf("age: want %d got %d", tt.wantAge, gotAge-age)
+			}
+			if got := notified.Load(); got != int32(tt.wantAge) {
+				t.Errorf("notified: want %d got %d", tt.wantAge, got)
+			}
+			if tt.wantAge > 0 && (batch.Load() == nil || len(batch.Load().Actions) != len(tt.actions)) {
+				t.Errorf("BatchAction: want %d actions got %v", len(tt.actions), batch.Load())
+			}
+		})
+	}
+}
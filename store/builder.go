@@ -0,0 +1,99 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrDuplicateHandler is returned by ReducerBuilder.Build when an action type has more than one handler.
+	ErrDuplicateHandler = errors.New("duplicate handler")
+
+	// ErrInterfaceAction is returned by ReducerBuilder.Build when a handler takes an interface type,
+	// handlers are dispatched by the concrete type of the action.
+	ErrInterfaceAction = errors.New("handler for interface action")
+)
+
+// Handler handles an action of a concrete type, created by On.
+type Handler[S State] struct {
+	actionType reflect.Type
+	handle     Reducer[S]
+}
+
+// On creates a handler for the actions of type A, like *addAction.
+func On[S State, A Action](handler func(state S, action A) (S, error)) Handler[S] {
+	return Handler[S]{
+		actionType: reflect.TypeOf((*A)(nil)).Elem(),
+		handle: func(state S, action Action) (S, error) {
+			return handler(state, action.(A))
+		},
+	}
+}
+
+// ReducerBuilder builds a reducer which calls the handler for the type of an action.
+type ReducerBuilder[S State] struct {
+	handlers map[reflect.Type]Reducer[S]
+	fallback Reducer[S]
+	err      error
+}
+
+// NewReducerBuilder creates ReducerBuilder.
+func NewReducerBuilder[S State]() *ReducerBuilder[S] {
+	return &ReducerBuilder[S]{
+		handlers: map[reflect.Type]Reducer[S]{},
+	}
+}
+
+// Handle adds handlers, the first error is returned by Build.
+func (c *ReducerBuilder[S]) Handle(handlers ...Handler[S]) *ReducerBuilder[S] {
+	for _, handler := range handlers {
+		if c.err != nil {
+			break
+		}
+		if handler.actionType.Kind() == reflect.Interface {
+			c.err = fmt.Errorf("%w: %v", ErrInterfaceAction, handler.actionType)
+			break
+		}
+		if _, ok := c.handlers[handler.actionType]; ok {
+			c.err = fmt.Errorf("%w: %v", ErrDuplicateHandler, handler.actionType)
+			break
+		}
+		c.handlers[handler.actionType] = handler.handle
+	}
+	return c
+}
+
+// Default sets the reducer for the actions without a handler, the state is not changed by default.
+func (c *ReducerBuilder[S]) Default(reducer Reducer[S]) *ReducerBuilder[S] {
+	c.fallback = reducer
+	return c
+}
+
+// Build returns the reducer, or the first error from Handle.
+func (c *ReducerBuilder[S]) Build() (Reducer[S], error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	// copied not to be changed after built
+	handlers := make(map[reflect.Type]Reducer[S], len(c.handlers))
+	for actionType, handler := range c.handlers {
+		handlers[actionType] = handler
+	}
+	fallback := c.fallback
+
+	return func(state S, action Action) (S, error) {
+		// support nil action
+		if action == nil {
+			return state, nil
+		}
+		if handler, ok := handlers[reflect.TypeOf(action)]; ok {
+			return handler(state, action)
+		}
+		if fallback != nil {
+			return fallback(state, action)
+		}
+		return state, nil
+	}, nil
+}
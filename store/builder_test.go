@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+type unknownAction struct{}
+
+func Test_ReducerBuilder(t *testing.T) {
+	add := On(func(state myState, action *addAction) (myState, error) {
+		state.value += action.value
+		return state, nil
+	})
+	set := On(func(state myState, action *setAction) (myState, error) {
+		state.value = action.value
+		return state, nil
+	})
+	fallback := func(state myState, action Action) (myState, error) {
+		state.value = "default"
+		return state, nil
+	}
+
+	tests := []struct {
+		name     string
+		handlers []Handler[myState]
+		fallback Reducer[myState]
+		actions  []Action
+		want     myState
+		wantErr  error
+	}{
+		{
+			name:     "by type",
+			handlers: []Handler[myState]{add, set},
+			actions:  []Action{&setAction{"1"}, &addAction{"2"}, nil},
+			want:     myState{value: "12"},
+		},
+		{
+			name:     "no handler",
+			handlers: []Handler[myState]{add},
+			actions:  []Action{&addAction{"1"}, &setAction{"2"}, &unknownAction{}},
+			want:     myState{value: "1"},
+		},
+		{
+			name:     "default",
+			handlers: []Handler[myState]{add},
+			fallback: fallback,
+			actions:  []Action{&addAction{"1"}, &unknownAction{}},
+			want:     myState{value: "default"},
+		},
+		{
+			name:     "value and pointer are different",
+			handlers: []Handler[myState]{add},
+			actions:  []Action{addAction{"1"}},
+			want:     myState{},
+		},
+		{
+			name:     "duplicate",
+			handlers: []Handler[myState]{add, set, add},
+			wantErr:  ErrDuplicateHandler,
+		},
+		{
+			name: "interface",
+			handlers: []Handler[myState]{On(func(state myState, action Action) (myState, error) {
+				return state, nil
+			})},
+			wantErr: ErrInterfaceAction,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			reducer, err := NewReducerBuilder[myState]().
+				Handle(tt.handlers...).
+				Default(tt.fallback).
+				Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build: want error %v got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			b := NewStore(myInitialState, reducer)
+			for _, action := range tt.actions {
+				b.Dispatch(action)
+			}
+			b.Stop()
+			b.WaitForStore()
+
+			assertState(t, b.GetState(), tt.want, nil)
+		})
+	}
+}
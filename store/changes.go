@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+)
+
+// Change is a state change notified to subscribers.
+type Change[S State] struct {
+	NewState S
+	OldState S
+	Action   Action
+	// Age is the age of NewState
+	Age int64
+	// Envelope is the envelope of Action
+	Envelope *Envelope
+}
+
+// OverflowPolicy decides what to do with a change when the channel of Changes is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the dispatcher until the change is received or ctx is done
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest change buffered
+	OverflowDropOldest
+	// OverflowDropNewest drops the change
+	OverflowDropNewest
+	// OverflowConflate buffers only the latest change with the OldState of the change dropped,
+	// bufferSize is ignored
+	OverflowConflate
+)
+
+func (c OverflowPolicy) String() string {
+	switch c {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowConflate:
+		return "conflate"
+	}
+	return "unknown"
+}
+
+func (b *baseStore[S]) Changes(ctx context.Context, bufferSize int, policy OverflowPolicy) <-chan Change[S] {
+	if b == nil {
+		return nil
+	}
+
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	// the policies dropping changes need a buffer to keep one at least
+	if policy != OverflowBlock && bufferSize == 0 || policy == OverflowConflate {
+		bufferSize = 1
+	}
+	changes := make(chan Change[S], bufferSize)
+
+	// the subscriber is the only sender, notified in the dispatch context one by one
+	_, disposer := b.subscribeChange(b.dispatchScheduler, func(change Change[S]) {
+		switch policy {
+		case OverflowDropOldest:
+			for {
+				select {
+				case changes <- change:
+					return
+				default:
+				}
+				select {
+				case <-changes:
+				default:
+				}
+			}
+		case OverflowDropNewest:
+			select {
+			case changes <- change:
+			default:
+			}
+		case OverflowConflate:
+			for {
+				select {
+				case changes <- change:
+					return
+				default:
+				}
+				select {
+				case dropped := <-changes:
+					change.OldState = dropped.OldState
+				default:
+				}
+			}
+		default:
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+			}
+		}
+	})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-b.done:
+		}
+		// no more sender after the notification running
+		disposer.DisposeAndWait()
+		close(changes)
+	}()
+
+	return changes
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_baseStore_Changes(t *testing.T) {
+
+	type change struct {
+		value string
+		old   string
+		age   int64
+	}
+
+	tests := []struct {
+		name       string
+		bufferSize int
+		policy     OverflowPolicy
+		want       []change
+	}{
+		{
+			name:       "drop newest",
+			bufferSize: 2,
+			policy:     OverflowDropNewest,
+			want:       []change{{"", "", 1}, {"1", "", 2}},
+		},
+		{
+			name:       "drop oldest",
+			bufferSize: 2,
+			policy:     OverflowDropOldest,
+			want:       []change{{"12", "1", 3}, {"123", "12", 4}},
+		},
+		{
+			name:       "conflate",
+			bufferSize: 2,
+			policy:     OverflowConflate,
+			want:       []change{{"123", "", 4}},
+		},
+		{
+			name:       "block with enough buffer",
+			bufferSize: 4,
+			policy:     OverflowBlock,
+			want:       []change{{"", "", 1}, {"1", "", 2}, {"12", "1", 3}, {"123", "12", 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMyStateStore()
+			changes := b.Changes(context.Background(), tt.bufferSize, tt.policy)
+
+			// not received until the store stopped
+			for _, value := range []string{"1", "2", "3"} {
+				if _, _, err := b.DispatchSync(context.Background(), &addAction{value}); err != nil {
+					t.Fatalf("DispatchSync: %v", err)
+				}
+			}
+			b.Stop()
+			b.WaitForStore()
+
+			var got []change
+			for c := range changes {
+				got = append(got, change{c.NewState.value, c.OldState.value, c.Age})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes: got %v want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("block until received", func(t *testing.T) {
+		b := newMyStateStore()
+		changes := b.Changes(context.Background(), 0, OverflowBlock)
+
+		b.Dispatch(&addAction{"1"})
+		b.Dispatch(&addAction{"2"})
+
+		var got []int64
+		for c := range changes {
+			got = append(got, c.Age)
+			if c.Age == 3 {
+				b.Stop()
+			}
+		}
+		b.WaitForStore()
+
+		if want := []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Changes: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("closed when ctx done", func(t *testing.T) {
+		b := newMyStateStore()
+		ctx, cancel := context.WithCancel(context.Background())
+		changes := b.Changes(ctx, 0, OverflowBlock)
+
+		// blocked in the dispatcher, released by cancel
+		b.Dispatch(&addAction{"1"})
+		cancel()
+
+		timeout := time.After(time.Second)
+	loop:
+		for {
+			select {
+			case _, ok := <-changes:
+				if !ok {
+					break loop
+				}
+			case <-timeout:
+				t.Fatalf("Changes: not closed")
+			}
+		}
+
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"2"}); err != nil {
+			t.Errorf("DispatchSync: %v", err)
+		}
+		b.Stop()
+		b.WaitForStore()
+		assertState(t, b.GetState(), myState{value: "12"}, nil)
+	})
+}
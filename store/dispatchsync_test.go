@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_baseStore_DispatchSync(t *testing.T) {
+
+	t.Run("returns the state after subscribers notified", func(t *testing.T) {
+		b := newMyStateStore()
+
+		var notified int64
+		b.SubscribeOn(sched.Background, func(newState myState, oldState myState, action Action) {
+			time.Sleep(10 * time.Millisecond)
+			atomic.StoreInt64(&notified, int64(len(newState.value)))
+		})
+
+		for idx, value := range []string{"1", "2", "3"} {
+			state, age, err := b.DispatchSync(context.Background(), &addAction{value})
+			if err != nil {
+				t.Fatalf("DispatchSync: %v", err)
+			}
+			if len(state.value) != idx+1 || age != int64(idx+2) {
+				t.Errorf("DispatchSync: got %v at %d", state, age)
+			}
+			if got := atomic.LoadInt64(&notified); got != int64(idx+1) {
+				t.Errorf("DispatchSync: subscriber notified want %d got %d", idx+1, got)
+			}
+		}
+
+		b.Stop()
+		b.WaitForStore()
+
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"4"}); !errors.Is(err, ErrStoreStopped) {
+			t.Errorf("DispatchSync: want %v got %v", ErrStoreStopped, err)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		b := newMyStateStore()
+
+		blocked := make(chan struct{})
+		b.Dispatch(AsyncAction(func(dispatcher Dispatcher) {
+			<-blocked
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, _, err := b.DispatchSync(ctx, &addAction{"dropped"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("DispatchSync: want %v got %v", context.DeadlineExceeded, err)
+		}
+		close(blocked)
+
+		// the store is still usable
+		state, _, err := b.DispatchSync(context.Background(), &addAction{"1"})
+		if err != nil {
+			t.Fatalf("DispatchSync: %v", err)
+		}
+		assertState(t, state, myState{value: "1"}, nil)
+
+		b.Stop()
+		b.WaitForStore()
+	})
+}
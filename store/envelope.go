@@ -0,0 +1,80 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Envelope carries an action with its identity and metadata.
+// Dispatch wraps an action into an envelope, or an *Envelope can be dispatched to set Source, Meta and so on.
+// reducers, middlewares and subscribers get the action, the envelope is available by Store.Envelope.
+type Envelope struct {
+	// ID is unique for an action dispatched, assigned if empty
+	ID string
+	// Time is when the action is dispatched, assigned if zero
+	Time time.Time
+	// Source is where the action comes from
+	Source string
+	// CorrelationID is shared by the actions caused by the same action, the ID if empty
+	CorrelationID string
+	// CausationID is the ID of the action caused this action
+	CausationID string
+	// Meta is arbitrary metadata
+	Meta map[string]any
+	// Action is the action enveloped
+	Action Action
+}
+
+// wrapAction wraps an action into an envelope with ID and Time, caused by cause if not nil.
+// an envelope is copied not to change the one dispatched, the fields set are kept.
+func wrapAction(action Action, cause *Envelope) *Envelope {
+	var envelope Envelope
+	if reified, ok := action.(*Envelope); ok && reified != nil {
+		envelope = *reified
+	} else {
+		envelope.Action = action
+	}
+
+	if envelope.ID == "" {
+		envelope.ID = newEnvelopeID()
+	}
+	if envelope.Time.IsZero() {
+		envelope.Time = time.Now()
+	}
+	if cause != nil {
+		if envelope.CausationID == "" {
+			envelope.CausationID = cause.ID
+		}
+		if envelope.CorrelationID == "" {
+			envelope.CorrelationID = cause.CorrelationID
+		}
+	}
+	if envelope.CorrelationID == "" {
+		envelope.CorrelationID = envelope.ID
+	}
+	return &envelope
+}
+
+var envelopeSeq atomic.Uint64
+
+func newEnvelopeID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// unique in the process at least
+		return fmt.Sprintf("%x-%d", time.Now().UnixNano(), envelopeSeq.Add(1))
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// causedDispatcher stamps the actions dispatched with the cause
+type causedDispatcher struct {
+	dispatcher Dispatcher
+	cause      *Envelope
+}
+
+func (c *causedDispatcher) Dispatch(action Action) {
+	c.dispatcher.Dispatch(wrapAction(action, c.cause))
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_baseStore_Envelope(t *testing.T) {
+
+	t.Run("unwrapped for reducers and subscribers", func(t *testing.T) {
+		var b Store[myState]
+		var reduced *Envelope
+		b = newMyStateStore().AddReducer(func(state myState, action Action) (myState, error) {
+			if _, ok := action.(*addAction); ok {
+				reduced = b.Envelope()
+			}
+			return state, nil
+		})
+		changes := b.Changes(context.Background(), 4, OverflowBlock)
+
+		b.Dispatch(&Envelope{
+			Source: "test",
+			Meta:   map[string]any{"user": "1"},
+			Action: &addAction{"1"},
+		})
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+		var got []Change[myState]
+		for change := range changes {
+			got = append(got, change)
+		}
+		if len(got) != 2 {
+			t.Fatalf("Changes: want 2 got %d", len(got))
+		}
+		if _, ok := got[1].Action.(*addAction); !ok {
+			t.Errorf("Action: want *addAction got %T", got[1].Action)
+		}
+
+		envelope := got[1].Envelope
+		if envelope == nil || envelope != reduced {
+			t.Fatalf("Envelope: want the same in reducers and subscribers, got %v %v", reduced, envelope)
+		}
+		if envelope.ID == "" || envelope.Time.IsZero() {
+			t.Errorf("Envelope: want ID and Time assigned, got %+v", envelope)
+		}
+		if envelope.Source != "test" || envelope.Meta["user"] != "1" {
+			t.Errorf("Envelope: want Source and Meta kept, got %+v", envelope)
+		}
+		if envelope.CorrelationID != envelope.ID || envelope.CausationID != "" {
+			t.Errorf("Envelope: want correlated by itself, got %+v", envelope)
+		}
+		if got[0].Envelope == nil || got[0].Envelope.ID == envelope.ID {
+			t.Errorf("Envelope: want unique ID, got %v %v", got[0].Envelope, envelope)
+		}
+	})
+
+	t.Run("caused by AsyncAction", func(t *testing.T) {
+		b := newMyStateStore()
+		changes := b.Changes(context.Background(), 4, OverflowBlock)
+
+		async := AsyncAction(func(dispatcher Dispatcher) {
+			go func() {
+				dispatcher.Dispatch(&addAction{"1"})
+				dispatcher.Dispatch(&addAction{"2"})
+			}()
+		})
+		b.Dispatch(&Envelope{CorrelationID: "request-1", Action: async})
+
+		var envelopes []*Envelope
+		for change := range changes {
+			if _, ok := change.Action.(*addAction); ok {
+				envelopes = append(envelopes, change.Envelope)
+			}
+			if len(envelopes) == 2 {
+				b.Stop()
+			}
+		}
+		b.WaitForStore()
+
+		for _, envelope := range envelopes {
+			if envelope.CausationID == "" || envelope.CausationID == envelope.ID {
+				t.Errorf("CausationID: want the AsyncAction, got %+v", envelope)
+			}
+			if envelope.CorrelationID != "request-1" {
+				t.Errorf("CorrelationID: want %v got %v", "request-1", envelope.CorrelationID)
+			}
+		}
+		if envelopes[0].CausationID != envelopes[1].CausationID {
+			t.Errorf("CausationID: want the same cause, got %v %v", envelopes[0].CausationID, envelopes[1].CausationID)
+		}
+	})
+	t.Run("caused later by api.Dispatch", func(t *testing.T) {
+		b := newMyStateStore()
+		apis := make(chan MiddlewareAPI[myState], 1)
+		ApplyMiddleware[myState](b, func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+			if reified, ok := action.(*addAction); ok && reified.value == "1" {
+				apis <- api
+			}
+			next(action)
+		})
+		changes := b.Changes(context.Background(), 4, OverflowBlock)
+
+		b.Dispatch(&addAction{"1"})
+		api := <-apis
+		// reduced in between
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"2"}); err != nil {
+			t.Fatalf("DispatchSync: %v", err)
+		}
+		api.Dispatch(&addAction{"3"})
+
+		envelopes := map[string]*Envelope{}
+		for change := range changes {
+			if reified, ok := change.Action.(*addAction); ok {
+				envelopes[reified.value] = change.Envelope
+			}
+			if len(envelopes) == 3 {
+				b.Stop()
+			}
+		}
+		b.WaitForStore()
+
+		if got := envelopes["3"].CausationID; got != envelopes["1"].ID {
+			t.Errorf("CausationID: want %v got %v, not %v", envelopes["1"].ID, got, envelopes["2"].ID)
+		}
+	})
+
+	t.Run("caused by a thunk", func(t *testing.T) {
+		b := newMyStateStore()
+		changes := b.Changes(context.Background(), 4, OverflowBlock)
+
+		RunThunk[myState](b, nil, func(ctx context.Context, dispatch Dispatcher, getState func() myState) (struct{}, error) {
+			dispatch.Dispatch(&addAction{"1"})
+			dispatch.Dispatch(&addAction{"2"})
+			return struct{}{}, nil
+		})
+
+		var envelopes []*Envelope
+		for change := range changes {
+			if _, ok := change.Action.(*addAction); ok {
+				envelopes = append(envelopes, change.Envelope)
+			}
+			if len(envelopes) == 2 {
+				b.Stop()
+			}
+		}
+		b.WaitForStore()
+
+		cause := envelopes[0].CausationID
+		if cause == "" || cause != envelopes[1].CausationID || envelopes[1].CorrelationID != cause {
+			t.Errorf("CausationID: want the thunk, got %+v %+v", envelopes[0], envelopes[1])
+		}
+	})
+}
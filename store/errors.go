@@ -0,0 +1,111 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErrorPolicy decides what the store does when a reducer returns an error.
+type ErrorPolicy int32
+
+const (
+	// ContinueOnError keeps reducing with the state the failed reducer returned, the default
+	ContinueOnError ErrorPolicy = iota
+	// DiscardOnError rolls back to the state before the action, subscribers are not notified
+	DiscardOnError
+	// StopOnError rolls back like DiscardOnError and stops the store
+	StopOnError
+)
+
+func (c ErrorPolicy) String() string {
+	switch c {
+	case ContinueOnError:
+		return "continue"
+	case DiscardOnError:
+		return "discard"
+	case StopOnError:
+		return "stop"
+	}
+	return "unknown"
+}
+
+// errorsBufferSize is the size of the channel of Errors
+const errorsBufferSize = 64
+
+// ReducerError is an error returned by a reducer.
+type ReducerError struct {
+	Action Action
+	// Reducer is the index of the reducer in the order added, -1 if the error is not from a reducer
+	Reducer int
+	// Age is the age of the store if the action were reduced
+	Age int64
+	Err error
+}
+
+func (c *ReducerError) Error() string {
+	return fmt.Sprintf("reducer %d: action %T at age %d: %s", c.Reducer, c.Action, c.Age, c.Err)
+}
+
+func (c *ReducerError) Unwrap() error {
+	return c.Err
+}
+
+// errorReporter delivers errors to handlers and the channel
+type errorReporter struct {
+	lock     sync.Mutex
+	handlers []*func(err *ReducerError)
+	errors   chan *ReducerError
+	closed   bool
+}
+
+func newErrorReporter() *errorReporter {
+	return &errorReporter{
+		errors: make(chan *ReducerError, errorsBufferSize),
+	}
+}
+
+func (c *errorReporter) onError(handler func(err *ReducerError)) Disposer {
+	entry := &handler
+	c.lock.Lock()
+	c.handlers = append(c.handlers, entry)
+	c.lock.Unlock()
+
+	return &baseDisposer{
+		dispose: func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			for idx := 0; idx < len(c.handlers); idx++ {
+				if c.handlers[idx] == entry {
+					c.handlers = append(c.handlers[:idx:idx], c.handlers[idx+1:]...)
+					break
+				}
+			}
+		},
+	}
+}
+
+func (c *errorReporter) report(err *ReducerError) {
+	c.lock.Lock()
+	handlers := c.handlers
+	if !c.closed {
+		select {
+		case c.errors <- err:
+		default:
+			// nobody reads
+		}
+	}
+	c.lock.Unlock()
+
+	for _, handler := range handlers {
+		(*handler)(err)
+	}
+}
+
+func (c *errorReporter) close() {
+	c.lock.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.errors)
+	}
+	c.lock.Unlock()
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+var errBadValue = errors.New("bad value")
+
+type failAction struct {
+	value string
+}
+
+// appends the value and fails
+func failingReducer(state myState, action Action) (myState, error) {
+	switch reified := action.(type) {
+	case *failAction:
+		return myState{id: state.id, value: state.value + reified.value}, errBadValue
+	}
+	return state, nil
+}
+
+func Test_baseStore_ErrorPolicy(t *testing.T) {
+
+	type args struct {
+		policy  ErrorPolicy
+		actions []Action
+	}
+	type testCaseErrorPolicy[S State] struct {
+		name       string
+		b          Store[S]
+		args       args
+		want       S
+		wantNotify int
+	}
+
+	actions := []Action{
+		&addAction{"1"},
+		&failAction{"x"},
+		&addAction{"2"},
+	}
+
+	tests := []testCaseErrorPolicy[myState]{
+		{
+			name: "continue",
+			b:    newMyStateStore(),
+			args: args{
+				policy:  ContinueOnError,
+				actions: actions,
+			},
+			want:       myState{value: "1x2"},
+			wantNotify: 1 + 3,
+		},
+		{
+			name: "discard",
+			b:    newMyStateStore(),
+			args: args{
+				policy:  DiscardOnError,
+				actions: actions,
+			},
+			want:       myState{value: "12"},
+			wantNotify: 1 + 2,
+		},
+		{
+			name: "stop",
+			b:    newMyStateStore(),
+			args: args{
+				policy:  StopOnError,
+				actions: actions,
+			},
+			want:       myState{value: "1"},
+			wantNotify: 1 + 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.b.AddReducer(failingReducer).SetErrorPolicy(tt.args.policy)
+
+			var notified int
+			tt.b.Subscribe(func(newState myState, oldState myState, action Action) {
+				notified++
+			})
+			var reported []*ReducerError
+			tt.b.OnError(func(err *ReducerError) {
+				reported = append(reported, err)
+			})
+
+			for _, action := range tt.args.actions {
+				tt.b.Dispatch(action)
+			}
+
+			tt.b.Stop()
+			tt.b.WaitForStore()
+
+			assertState(t, tt.b.GetState(), tt.want, tt.args.actions)
+			if tt.wantNotify != notified {
+				t.Errorf("ErrorPolicy: notified want %d got %d", tt.wantNotify, notified)
+			}
+
+			// InitAction is 1
+			if len(reported) != 1 || reported[0].Reducer != 1 || reported[0].Age != 3 || !errors.Is(reported[0], errBadValue) {
+				t.Fatalf("OnError: got %v", reported)
+			}
+			var streamed []*ReducerError
+			for err := range tt.b.Errors() {
+				streamed = append(streamed, err)
+			}
+			if len(streamed) != 1 || streamed[0] != reported[0] {
+				t.Errorf("Errors: got %v", streamed)
+			}
+		})
+	}
+}
+
+func Test_baseStore_ErrorPolicy_DispatchSync(t *testing.T) {
+
+	t.Run("discarded action returns the error", func(t *testing.T) {
+		b := newMyStateStore()
+		b.AddReducer(failingReducer).SetErrorPolicy(DiscardOnError)
+
+		state, age, err := b.DispatchSync(context.Background(), &failAction{"x"})
+		var reducerErr *ReducerError
+		if !errors.As(err, &reducerErr) || !errors.Is(err, errBadValue) {
+			t.Errorf("DispatchSync: want ReducerError got %v", err)
+		}
+		if state.value != "" || age != 1 {
+			t.Errorf("DispatchSync: got %v at %d", state, age)
+		}
+
+		b.Stop()
+		b.WaitForStore()
+	})
+
+	t.Run("StopOnError on Immediate", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			dispatch func(b Store[myState])
+		}{
+			{
+				name: "Dispatch",
+				dispatch: func(b Store[myState]) {
+					b.Dispatch(&failAction{"x"})
+				},
+			},
+			{
+				name: "Transaction",
+				dispatch: func(b Store[myState]) {
+					b.Transaction(func(tx Dispatcher) error {
+						tx.Dispatch(&failAction{"x"})
+						return nil
+					})
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				b := NewStoreOn(sched.Immediate, myInitialState, myStateReducer)
+				b.AddReducer(failingReducer).SetErrorPolicy(StopOnError)
+
+				stopped := make(chan struct{})
+				go func() {
+					defer close(stopped)
+					b.Dispatch(&addAction{"1"})
+					tt.dispatch(b)
+					b.WaitForStore()
+				}()
+				select {
+				case <-stopped:
+				case <-time.After(time.Second):
+					t.Fatalf("StopOnError: blocked")
+				}
+
+				if b.Status() != StatusStopped {
+					t.Errorf("Status: want %v got %v", StatusStopped, b.Status())
+				}
+				assertState(t, b.GetState(), myState{value: "1"}, nil)
+			})
+		}
+	})
+}
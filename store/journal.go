@@ -0,0 +1,317 @@
+package store
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rookiecj/go-store/logger"
+	"github.com/rookiecj/go-store/sched"
+)
+
+var (
+	// ErrReplayDiverged is returned when a replayed state differs from the recorded one.
+	ErrReplayDiverged = errors.New("replay diverged")
+
+	// ErrUnknownAction is returned when an action is not registered to a codec.
+	ErrUnknownAction = errors.New("unknown action")
+
+	// ErrJournalGap is returned by Replay when an action reduced is missing in the journal.
+	ErrJournalGap = errors.New("journal gap")
+)
+
+// batchActionName is the name of BatchAction encoded by JSONActionCodec
+const batchActionName = "store.BatchAction"
+
+// JournalEntry is an action recorded with the age and the resulting state.
+type JournalEntry struct {
+	Age  int64     `json:"age"`
+	Time time.Time `json:"time"`
+	// Action is encoded by ActionCodec
+	Action    json.RawMessage `json:"action"`
+	StateHash string          `json:"state"`
+	// Err is why the action is not encoded, Replay stops at the entry
+	Err string `json:"error,omitempty"`
+}
+
+// Journal is an append-only list of entries.
+type Journal interface {
+	Append(entry JournalEntry) error
+}
+
+// ActionCodec encodes and decodes actions for the journal.
+type ActionCodec interface {
+	EncodeAction(action Action) ([]byte, error)
+	DecodeAction(data []byte) (Action, error)
+}
+
+// StateHash returns a hash of the state to compare states.
+type StateHash[S State] func(state S) string
+
+// HashState hashes the state printed with %#v, unexported fields are included.
+// pointers in the state are hashed with their address, use own StateHash for them.
+func HashState[S State](state S) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", state)))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordJournal records actions reaching reducers with the resulting state to the journal.
+// it should be applied after the other middlewares to record what reducers get.
+// an action failed to encode is recorded with Err as a gap.
+func RecordJournal[S State](store Store[S], journal Journal, codec ActionCodec, hash StateHash[S]) Store[S] {
+	if hash == nil {
+		hash = HashState[S]
+	}
+	return ApplyMiddleware(store, func(api MiddlewareAPI[S], next DispatchFunc, action Action) {
+		_, lastAge := store.Snapshot()
+		next(action)
+		state, age := store.Snapshot()
+		if age == lastAge {
+			// not reduced
+			return
+		}
+
+		entry := JournalEntry{
+			Age:       age,
+			Time:      time.Now(),
+			StateHash: hash(state),
+		}
+		data, err := codec.EncodeAction(action)
+		if err != nil {
+			logger.Errf("Store: journal: encode %T: %s\n", action, err)
+			entry.Err = fmt.Sprintf("encode %T: %s", action, err)
+		} else {
+			entry.Action = data
+		}
+		if err := journal.Append(entry); err != nil {
+			logger.Errf("Store: journal: append: %s\n", err)
+		}
+	})
+}
+
+// DivergenceError reports the first age where the replayed state differs.
+type DivergenceError struct {
+	Age  int64
+	Want string
+	Got  string
+}
+
+func (c *DivergenceError) Error() string {
+	return fmt.Sprintf("%s at age %d: want %s got %s", ErrReplayDiverged, c.Age, c.Want, c.Got)
+}
+
+func (c *DivergenceError) Unwrap() error {
+	return ErrReplayDiverged
+}
+
+// Replay reduces the recorded actions in a fresh store on sched.Immediate,
+// returns the replayed state and *DivergenceError at the first age where the state differs from the recorded.
+// ErrJournalGap is returned at the first age missing or recorded with Err.
+func Replay[S State](entries []JournalEntry, codec ActionCodec, hash StateHash[S], initialState S, reducers ...Reducer[S]) (state S, err error) {
+	if hash == nil {
+		hash = HashState[S]
+	}
+	if len(reducers) == 0 {
+		reducers = []Reducer[S]{func(state S, action Action) (S, error) {
+			return state, nil
+		}}
+	}
+
+	store := NewStoreOn(sched.Immediate, initialState, reducers[0])
+	for _, reducer := range reducers[1:] {
+		store.AddReducer(reducer)
+	}
+
+	for _, entry := range entries {
+		if _, age := store.Snapshot(); entry.Age != age+1 {
+			return store.GetState(), fmt.Errorf("%w at age %d: got age %d", ErrJournalGap, age+1, entry.Age)
+		}
+		if entry.Err != "" {
+			return store.GetState(), fmt.Errorf("%w at age %d: %s", ErrJournalGap, entry.Age, entry.Err)
+		}
+		action, err := codec.DecodeAction(entry.Action)
+		if err != nil {
+			return store.GetState(), fmt.Errorf("decode action at age %d: %w", entry.Age, err)
+		}
+		store.Dispatch(action)
+
+		state = store.GetState()
+		if got := hash(state); got != entry.StateHash {
+			return state, &DivergenceError{
+				Age:  entry.Age,
+				Want: entry.StateHash,
+				Got:  got,
+			}
+		}
+	}
+	return store.GetState(), nil
+}
+
+// MemoryJournal keeps entries in memory.
+type MemoryJournal struct {
+	lock    sync.Mutex
+	entries []JournalEntry
+}
+
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{}
+}
+
+func (c *MemoryJournal) Append(entry JournalEntry) error {
+	c.lock.Lock()
+	c.entries = append(c.entries, entry)
+	c.lock.Unlock()
+	return nil
+}
+
+// Entries returns a copy of the entries.
+func (c *MemoryJournal) Entries() []JournalEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]JournalEntry(nil), c.entries...)
+}
+
+// jsonJournal writes entries as JSON lines
+type jsonJournal struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONJournal creates a journal writing an entry per line,
+// the writer should be opened to append like os.O_APPEND.
+func NewJSONJournal(writer io.Writer) Journal {
+	return &jsonJournal{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+func (c *jsonJournal) Append(entry JournalEntry) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.encoder.Encode(entry)
+}
+
+// ReadJSONJournal reads entries written by NewJSONJournal.
+func ReadJSONJournal(reader io.Reader) (entries []JournalEntry, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024*64)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	err = scanner.Err()
+	return
+}
+
+// JSONActionCodec encodes actions in JSON with the registered names.
+// only exported fields of actions are encoded, BatchAction is encoded with its actions.
+type JSONActionCodec struct {
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+type jsonAction struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func NewJSONActionCodec() *JSONActionCodec {
+	return &JSONActionCodec{
+		types: map[string]reflect.Type{},
+		names: map[reflect.Type]string{},
+	}
+}
+
+// Register registers the type of the action with the name, action can be a pointer or a value.
+func (c *JSONActionCodec) Register(name string, action Action) *JSONActionCodec {
+	actionType := reflect.TypeOf(action)
+	c.types[name] = actionType
+	c.names[actionType] = name
+	return c
+}
+
+func (c *JSONActionCodec) EncodeAction(action Action) ([]byte, error) {
+	if batch, ok := action.(*BatchAction); ok {
+		actions := make([]json.RawMessage, 0, len(batch.Actions))
+		for _, action := range batch.Actions {
+			data, err := c.EncodeAction(action)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, data)
+		}
+		payload, err := json.Marshal(actions)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(jsonAction{
+			Type:    batchActionName,
+			Payload: payload,
+		})
+	}
+
+	name, ok := c.names[reflect.TypeOf(action)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownAction, action)
+	}
+	payload, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonAction{
+		Type:    name,
+		Payload: payload,
+	})
+}
+
+func (c *JSONActionCodec) DecodeAction(data []byte) (Action, error) {
+	var encoded jsonAction
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	if encoded.Type == batchActionName {
+		var encodedActions []json.RawMessage
+		if err := json.Unmarshal(encoded.Payload, &encodedActions); err != nil {
+			return nil, err
+		}
+		batch := &BatchAction{}
+		for _, data := range encodedActions {
+			action, err := c.DecodeAction(data)
+			if err != nil {
+				return nil, err
+			}
+			batch.Actions = append(batch.Actions, action)
+		}
+		return batch, nil
+	}
+	actionType, ok := c.types[encoded.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAction, encoded.Type)
+	}
+
+	if actionType.Kind() == reflect.Pointer {
+		action := reflect.New(actionType.Elem())
+		if err := json.Unmarshal(encoded.Payload, action.Interface()); err != nil {
+			return nil, err
+		}
+		return action.Interface(), nil
+	}
+	action := reflect.New(actionType)
+	if err := json.Unmarshal(encoded.Payload, action.Interface()); err != nil {
+		return nil, err
+	}
+	return action.Elem().Interface(), nil
+}
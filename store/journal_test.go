@@ -0,0 +1,156 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type appendAction struct {
+	Value string `json:"value"`
+}
+
+type clearAction struct{}
+
+func journalReducer(state myState, action Action) (myState, error) {
+	switch reified := action.(type) {
+	case *appendAction:
+		return myState{id: state.id + 1, value: state.value + reified.Value}, nil
+	case clearAction:
+		return myState{id: state.id + 1}, nil
+	}
+	return state, nil
+}
+
+func newJournalCodec() *JSONActionCodec {
+	return NewJSONActionCodec().
+		Register("append", &appendAction{}).
+		Register("clear", clearAction{})
+}
+
+func Test_RecordJournal_Replay(t *testing.T) {
+
+	actions := []Action{
+		&appendAction{"a"},
+		&appendAction{"b"},
+		clearAction{},
+		&appendAction{"c"},
+	}
+
+	record := func(t *testing.T) []JournalEntry {
+		journal := NewMemoryJournal()
+		b := NewStore(myInitialState, journalReducer)
+		RecordJournal(b, journal, newJournalCodec(), nil)
+		for _, action := range actions {
+			b.Dispatch(action)
+		}
+		b.Stop()
+		b.WaitForStore()
+
+		entries := journal.Entries()
+		if len(entries) != len(actions) {
+			t.Fatalf("RecordJournal: entries want %d got %d", len(actions), len(entries))
+		}
+		for idx, entry := range entries {
+			// InitAction is 1
+			if entry.Age != int64(idx+2) {
+				t.Errorf("RecordJournal: age want %d got %d", idx+2, entry.Age)
+			}
+		}
+		return entries
+	}
+
+	t.Run("replay from JSON journal", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		journal := NewJSONJournal(buffer)
+		for _, entry := range record(t) {
+			if err := journal.Append(entry); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+
+		entries, err := ReadJSONJournal(buffer)
+		if err != nil {
+			t.Fatalf("ReadJSONJournal: %v", err)
+		}
+
+		got, err := Replay(entries, newJournalCodec(), nil, myInitialState, journalReducer)
+		if err != nil {
+			t.Errorf("Replay: %v", err)
+		}
+		assertState(t, got, myState{id: 4, value: "c"}, nil)
+	})
+
+	t.Run("report the first age diverged", func(t *testing.T) {
+		buggyReducer := func(state myState, action Action) (myState, error) {
+			if _, ok := action.(clearAction); ok {
+				// forget to clear
+				return myState{id: state.id + 1, value: state.value}, nil
+			}
+			return journalReducer(state, action)
+		}
+
+		_, err := Replay(record(t), newJournalCodec(), nil, myInitialState, buggyReducer)
+		var divergence *DivergenceError
+		if !errors.As(err, &divergence) || !errors.Is(err, ErrReplayDiverged) {
+			t.Fatalf("Replay: want DivergenceError got %v", err)
+		}
+		if divergence.Age != 4 {
+			t.Errorf("Replay: diverged age want %d got %d", 4, divergence.Age)
+		}
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := newJournalCodec().EncodeAction(&addAction{"1"})
+		if !errors.Is(err, ErrUnknownAction) {
+			t.Errorf("EncodeAction: want ErrUnknownAction got %v", err)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		journal := NewMemoryJournal()
+		b := NewStore(myInitialState, journalReducer)
+		RecordJournal(b, journal, newJournalCodec(), nil)
+		b.DispatchBatch(&appendAction{"a"}, &appendAction{"b"})
+		b.Transaction(func(tx Dispatcher) error {
+			tx.Dispatch(clearAction{})
+			tx.Dispatch(&appendAction{"c"})
+			return nil
+		})
+		b.Stop()
+		b.WaitForStore()
+
+		got, err := Replay(journal.Entries(), newJournalCodec(), nil, myInitialState, journalReducer)
+		if err != nil {
+			t.Errorf("Replay: %v", err)
+		}
+		assertState(t, got, b.GetState(), nil)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		journal := NewMemoryJournal()
+		b := NewStore(myInitialState, journalReducer)
+		RecordJournal(b, journal, newJournalCodec(), nil)
+		b.Dispatch(&appendAction{"a"})
+		// not registered
+		b.Dispatch(&addAction{"b"})
+		b.Dispatch(&appendAction{"c"})
+		b.Stop()
+		b.WaitForStore()
+
+		entries := journal.Entries()
+		if len(entries) != 3 || entries[1].Err == "" {
+			t.Fatalf("RecordJournal: want a gap at 3 got %v", entries)
+		}
+		if _, err := Replay(entries, newJournalCodec(), nil, myInitialState, journalReducer); !errors.Is(err, ErrJournalGap) {
+			t.Errorf("Replay: want ErrJournalGap got %v", err)
+		}
+
+		// missing age
+		missing := []JournalEntry{entries[0], entries[2]}
+		_, err := Replay(missing, newJournalCodec(), nil, myInitialState, journalReducer)
+		if !errors.Is(err, ErrJournalGap) || errors.Is(err, ErrReplayDiverged) {
+			t.Errorf("Replay: want ErrJournalGap got %v", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_baseStore_Lifecycle(t *testing.T) {
+
+	t.Run("running - stopping - stopped", func(t *testing.T) {
+		b := newMyStateStore()
+		if b.Status() != StatusRunning {
+			t.Errorf("Status: want %v got %v", StatusRunning, b.Status())
+		}
+
+		blocked := make(chan struct{})
+		b.Dispatch(AsyncAction(func(dispatcher Dispatcher) {
+			<-blocked
+		}))
+		if err := b.TryDispatch(&addAction{"1"}); err != nil {
+			t.Errorf("TryDispatch: want nil got %v", err)
+		}
+
+		b.Stop()
+		if b.Status() != StatusStopping {
+			t.Errorf("Status: want %v got %v", StatusStopping, b.Status())
+		}
+		if err := b.TryDispatch(&addAction{"2"}); !errors.Is(err, ErrStoreStopped) {
+			t.Errorf("TryDispatch: want %v got %v", ErrStoreStopped, err)
+		}
+		close(blocked)
+
+		<-b.Done()
+		if b.Status() != StatusStopped {
+			t.Errorf("Status: want %v got %v", StatusStopped, b.Status())
+		}
+		b.WaitForStore()
+
+		// accepted before Stop
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+	})
+
+	t.Run("on Main", func(t *testing.T) {
+		b := NewStoreOn(sched.Main, myInitialState, myStateReducer)
+		b.Dispatch(&addAction{"1"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		if err := b.TryDispatch(&addAction{"2"}); !errors.Is(err, ErrStoreStopped) {
+			t.Errorf("TryDispatch: want %v got %v", ErrStoreStopped, err)
+		}
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		b := NewStoreOn(sched.NewMainSchedulerWithCapacity(1), myInitialState, myStateReducer)
+
+		blocked := make(chan struct{})
+		running := make(chan struct{})
+		block := AsyncAction(func(dispatcher Dispatcher) {
+			close(running)
+			<-blocked
+		})
+		// InitAction may be in the queue
+		for b.TryDispatch(block) != nil {
+			time.Sleep(time.Millisecond)
+		}
+		<-running
+
+		if err := b.TryDispatch(&addAction{"1"}); err != nil {
+			t.Errorf("TryDispatch: want nil got %v", err)
+		}
+		if err := b.TryDispatch(&addAction{"2"}); !errors.Is(err, sched.ErrQueueFull) {
+			t.Errorf("TryDispatch: want %v got %v", sched.ErrQueueFull, err)
+		}
+		close(blocked)
+
+		b.Stop()
+		b.WaitForStore()
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+	})
+
+	t.Run("Stop in a task on Immediate", func(t *testing.T) {
+		b := NewStoreOn(sched.Immediate, myInitialState, myStateReducer)
+		b.SubscribeOn(sched.Immediate, func(state myState, old myState, action Action) {
+			if state.value == "1" {
+				b.Stop()
+				// not accepted, not blocked
+				if err := b.TryDispatch(&addAction{"2"}); !errors.Is(err, ErrStoreStopped) {
+					t.Errorf("TryDispatch: want %v got %v", ErrStoreStopped, err)
+				}
+			}
+		})
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			b.Dispatch(&addAction{"1"})
+			b.WaitForStore()
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatalf("Stop: blocked in the task")
+		}
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+	})
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"github.com/rookiecj/go-store/logger"
+)
+
+// DispatchFunc passes an action to the next stage of the middleware chain.
+type DispatchFunc func(action Action)
+
+// MiddlewareAPI is what a middleware can do with the store.
+type MiddlewareAPI[S State] interface {
+	Dispatcher
+
+	// GetState returns the state in the dispatch context.
+	GetState() S
+
+	// Envelope returns the envelope of the action passing through the chain,
+	// the actions dispatched by the api are caused by it.
+	Envelope() *Envelope
+
+	// NextLater returns next to be called out of the dispatch context, to delay the action e.g.
+	// the action is passed to the next stage in the dispatch context with the envelope, dropped if the store is stopped.
+	NextLater() DispatchFunc
+}
+
+// Middleware intercepts an action before it reaches reducers.
+// it is called in the dispatch context with the next stage of the chain,
+// a middleware can pass, transform, swallow, delay or fan out the action by calling next (or not).
+// next should be called in the dispatch context, use api.NextLater to call it later,
+// or api.Dispatch to dispatch through the chain again.
+type Middleware[S State] func(api MiddlewareAPI[S], next DispatchFunc, action Action)
+
+// ApplyMiddleware appends middlewares to the store.
+// an action goes through middlewares in the order of them applied.
+func ApplyMiddleware[S State](store Store[S], middlewares ...Middleware[S]) Store[S] {
+	if store == nil {
+		return store
+	}
+	store.applyMiddleware(middlewares...)
+	return store
+}
+
+// AsyncActionMiddleware runs AsyncAction with the store as Dispatcher,
+// the actions dispatched later are caused by the AsyncAction.
+// the other actions are passed to the next.
+func AsyncActionMiddleware[S State]() Middleware[S] {
+	return func(api MiddlewareAPI[S], next DispatchFunc, action Action) {
+		if reified, ok := action.(AsyncAction); ok {
+			// api dispatches the actions caused by the AsyncAction
+			reified(api)
+			return
+		}
+		next(action)
+	}
+}
+
+// LoggingMiddleware logs an action and the states before and after reducing it.
+// logf is logger.Debugf if nil
+func LoggingMiddleware[S State](logf func(fmt string, args ...interface{})) Middleware[S] {
+	if logf == nil {
+		logf = logger.Debugf
+	}
+	return func(api MiddlewareAPI[S], next DispatchFunc, action Action) {
+		logf("Store: action: %T %v, state: %v\n", action, action, api.GetState())
+		next(action)
+		logf("Store: action: %T, next state: %v\n", action, api.GetState())
+	}
+}
+
+// middlewareAPI is made for each action passing through a middleware, with the envelope of the action
+type middlewareAPI[S State] struct {
+	store *baseStore[S]
+	cause *Envelope
+	next  DispatchFunc
+}
+
+func (c *middlewareAPI[S]) Dispatch(action Action) {
+	c.store.Dispatch(wrapAction(action, c.cause))
+}
+
+func (c *middlewareAPI[S]) GetState() S {
+	return c.store.state
+}
+
+func (c *middlewareAPI[S]) Envelope() *Envelope {
+	return c.cause
+}
+
+func (c *middlewareAPI[S]) NextLater() DispatchFunc {
+	return func(action Action) {
+		if err := c.store.scheduleAction(c.store.dispatchScheduler, func() {
+			c.store.runNext(c.cause, c.next, action)
+		}); err != nil {
+			logger.Errf("Store: next %T: %s\n", action, err)
+		}
+	}
+}
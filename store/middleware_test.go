@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ApplyMiddleware(t *testing.T) {
+
+	type args[S State] struct {
+		middlewares []Middleware[S]
+		actions     []Action
+		wait        time.Duration // for actions dispatched later
+	}
+	type testCaseMiddleware[S State] struct {
+		name string
+		b    Store[S]
+		args args[S]
+		want string
+	}
+
+	// transform setAction to addAction
+	transform := func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+		if reified, ok := action.(*setAction); ok {
+			next(&addAction{value: reified.value})
+			return
+		}
+		next(action)
+	}
+	// swallow addAction with empty value
+	swallow := func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+		if reified, ok := action.(*addAction); ok && reified.value == "" {
+			return
+		}
+		next(action)
+	}
+	// fan out addAction twice
+	fanout := func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+		next(action)
+		if _, ok := action.(*addAction); ok {
+			next(action)
+		}
+	}
+
+	tests := []testCaseMiddleware[myState]{
+		{
+			name: "no middleware",
+			b:    newMyStateStore(),
+			args: args[myState]{
+				middlewares: nil,
+				actions: []Action{
+					&addAction{"1"},
+					&setAction{"2"},
+				},
+			},
+			want: "2",
+		},
+		{
+			name: "transform",
+			b:    newMyStateStore(),
+			args: args[myState]{
+				middlewares: []Middleware[myState]{transform},
+				actions: []Action{
+					&addAction{"1"},
+					&setAction{"2"},
+				},
+			},
+			want: "12",
+		},
+		{
+			name: "swallow",
+			b:    newMyStateStore(),
+			args: args[myState]{
+				middlewares: []Middleware[myState]{swallow},
+				actions: []Action{
+					&addAction{"1"},
+					&addAction{""},
+					&addAction{"3"},
+				},
+			},
+			want: "13",
+		},
+		{
+			name: "fan out after transform",
+			b:    newMyStateStore(),
+			args: args[myState]{
+				middlewares: []Middleware[myState]{transform, fanout},
+				actions: []Action{
+					&setAction{"1"},
+					&addAction{"2"},
+				},
+			},
+			want: "1122",
+		},
+		{
+			name: "async action",
+			b:    newMyStateStore(),
+			args: args[myState]{
+				middlewares: []Middleware[myState]{fanout},
+				actions: []Action{
+					AsyncAction(func(dispatcher Dispatcher) {
+						dispatcher.Dispatch(&addAction{"1"})
+					}),
+				},
+				wait: 100 * time.Millisecond,
+			},
+			want: "11",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ApplyMiddleware(tt.b, tt.args.middlewares...)
+
+			for _, action := range tt.args.actions {
+				tt.b.Dispatch(action)
+			}
+			time.Sleep(tt.args.wait)
+
+			tt.b.Stop()
+			tt.b.WaitForStore()
+
+			got := tt.b.GetState()
+			if tt.want != got.value {
+				t.Errorf("ApplyMiddleware: want %s got %s", tt.want, got.value)
+			}
+		})
+	}
+}
+
+func Test_ApplyMiddleware_Order(t *testing.T) {
+
+	t.Run("middlewares called in order around reducing", func(t *testing.T) {
+		b := newMyStateStore()
+
+		var called []string
+		tracer := func(name string) Middleware[myState] {
+			return func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+				called = append(called, name+":"+api.GetState().value)
+				next(action)
+				called = append(called, name+":"+api.GetState().value)
+			}
+		}
+
+		var logged []string
+		logf := func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		}
+
+		ApplyMiddleware(b, tracer("first"), tracer("second"), LoggingMiddleware[myState](logf))
+		b.Dispatch(&addAction{"1"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		want := []string{"first:", "second:", "second:1", "first:1"}
+		if !reflect.DeepEqual(want, called) {
+			t.Errorf("ApplyMiddleware: want %v got %v", want, called)
+		}
+		if len(logged) != 2 || !strings.Contains(logged[0], "addAction") {
+			t.Errorf("LoggingMiddleware: got %v", logged)
+		}
+	})
+	t.Run("delayed by NextLater", func(t *testing.T) {
+		b := newMyStateStore()
+
+		// called in the dispatch context only
+		var called []string
+		outer := func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+			called = append(called, "outer:"+action.(*addAction).value)
+			next(action)
+		}
+		release, done := make(chan struct{}), make(chan struct{})
+		delay := func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+			called = append(called, "delay:"+action.(*addAction).value)
+			if action.(*addAction).value != "1" {
+				next(action)
+				return
+			}
+			later := api.NextLater()
+			go func() {
+				defer close(done)
+				<-release
+				later(action)
+			}()
+		}
+		var envelopes []string
+		b.Subscribe(func(newState myState, oldState myState, action Action) {
+			envelopes = append(envelopes, b.Envelope().Action.(*addAction).value)
+		})
+
+		ApplyMiddleware(b, outer, delay)
+		b.Dispatch(&addAction{"1"})
+		b.Dispatch(&addAction{"2"})
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"3"}); err != nil {
+			t.Fatalf("DispatchSync: %v", err)
+		}
+		close(release)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("NextLater: not called")
+		}
+
+		b.Stop()
+		b.WaitForStore()
+
+		if got := b.GetState().value; got != "231" {
+			t.Errorf("NextLater: want %s got %s", "231", got)
+		}
+		want := []string{"outer:1", "delay:1", "outer:2", "delay:2", "outer:3", "delay:3"}
+		if !reflect.DeepEqual(want, called) {
+			t.Errorf("NextLater: want the chain not run again %v got %v", want, called)
+		}
+		if !reflect.DeepEqual([]string{"2", "3", "1"}, envelopes) {
+			t.Errorf("NextLater: want the envelope of the action got %v", envelopes)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrMutation is wrapped by MutationError.
+var ErrMutation = errors.New("state mutated")
+
+// where a mutation is found
+const (
+	MutationInReducer    = "reducer"
+	MutationInSubscriber = "subscriber"
+)
+
+// MutationError reports a state mutated by a reducer or a subscriber, found by SetMutationCheck.
+// it is reported as ReducerError, with Reducer -1 for a subscriber.
+type MutationError struct {
+	// Source is MutationInReducer or MutationInSubscriber
+	Source string
+	// Path is where mutated first like state.items[0].name
+	Path   string
+	Before string
+	After  string
+}
+
+func (c *MutationError) Error() string {
+	return fmt.Sprintf("state mutated by %s at %s: %s -> %s", c.Source, c.Path, c.Before, c.After)
+}
+
+func (c *MutationError) Unwrap() error {
+	return ErrMutation
+}
+
+// fingerprint is the values of a state by path
+type fingerprint map[string]string
+
+func fingerprintOf(value any) fingerprint {
+	prints := fingerprint{}
+	walkValue(reflect.ValueOf(value), "state", prints, map[visit]bool{})
+	return prints
+}
+
+// mutated returns the error for the first path changed
+func (c fingerprint) mutated(source string, other fingerprint) *MutationError {
+	var paths []string
+	for path, value := range c {
+		if other[path] != value {
+			paths = append(paths, path)
+		}
+	}
+	for path := range other {
+		if _, ok := c[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	sort.Strings(paths)
+	path := paths[0]
+	before, ok := c[path]
+	if !ok {
+		before = "<none>"
+	}
+	after, ok := other[path]
+	if !ok {
+		after = "<none>"
+	}
+	return &MutationError{
+		Source: source,
+		Path:   path,
+		Before: before,
+		After:  after,
+	}
+}
+
+// visit is a pointer being walked, not to walk a cycle
+type visit struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+// walkValue records the values reachable from v, unexported fields as well
+func walkValue(v reflect.Value, path string, prints fingerprint, visiting map[visit]bool) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		prints[path] = "nil"
+	case reflect.Pointer:
+		if v.IsNil() {
+			prints[path] = "nil"
+			return
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if visiting[key] {
+			prints[path] = "<cycle>"
+			return
+		}
+		visiting[key] = true
+		walkValue(v.Elem(), path, prints, visiting)
+		delete(visiting, key)
+	case reflect.Interface:
+		if v.IsNil() {
+			prints[path] = "nil"
+			return
+		}
+		walkValue(v.Elem(), path, prints, visiting)
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			walkValue(v.Field(idx), path+"."+v.Type().Field(idx).Name, prints, visiting)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			prints[path] = "nil"
+			return
+		}
+		fallthrough
+	case reflect.Array:
+		prints[path] = "len " + strconv.Itoa(v.Len())
+		for idx := 0; idx < v.Len(); idx++ {
+			walkValue(v.Index(idx), path+"["+strconv.Itoa(idx)+"]", prints, visiting)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			prints[path] = "nil"
+			return
+		}
+		prints[path] = "len " + strconv.Itoa(v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			walkValue(iter.Value(), path+"["+keyString(iter.Key())+"]", prints, visiting)
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		prints[path] = fmt.Sprintf("%#x", v.Pointer())
+	default:
+		prints[path] = leafString(v)
+	}
+}
+
+func keyString(key reflect.Value) string {
+	switch key.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Interface, reflect.Pointer:
+		prints := fingerprint{}
+		walkValue(key, "", prints, map[visit]bool{})
+		pairs := make([]string, 0, len(prints))
+		for path, value := range prints {
+			pairs = append(pairs, path+"="+value)
+		}
+		sort.Strings(pairs)
+		return "{" + strings.Join(pairs, ",") + "}"
+	}
+	return leafString(key)
+}
+
+func leafString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 128)
+	case reflect.String:
+		return strconv.Quote(v.String())
+	}
+	return v.Kind().String()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type listState struct {
+	items []string
+	tags  map[string]int
+	owner *string
+}
+
+func (c listState) StateInterface() {}
+
+type appendItemAction struct {
+	item string
+}
+
+// copies on write
+func listReducer(state listState, action Action) (listState, error) {
+	switch reified := action.(type) {
+	case *appendItemAction:
+		items := make([]string, 0, len(state.items)+1)
+		state.items = append(append(items, state.items...), reified.item)
+	}
+	return state, nil
+}
+
+func Test_baseStore_SetMutationCheck(t *testing.T) {
+	owner := "owner"
+	initialState := listState{
+		items: []string{"a"},
+		tags:  map[string]int{"a": 1},
+		owner: &owner,
+	}
+
+	tests := []struct {
+		name       string
+		reducer    Reducer[listState]
+		subscriber Subscriber[listState]
+		wantPath   string
+		wantSource string
+		wantIndex  int
+	}{
+		{
+			name: "no mutation",
+		},
+		{
+			name: "reducer mutates a map",
+			reducer: func(state listState, action Action) (listState, error) {
+				if _, ok := action.(*appendItemAction); ok {
+					state.tags["b"] = 2
+				}
+				return state, nil
+			},
+			wantPath:   "state.tags",
+			wantSource: MutationInReducer,
+			wantIndex:  1,
+		},
+		{
+			name: "reducer mutates via a pointer",
+			reducer: func(state listState, action Action) (listState, error) {
+				if _, ok := action.(*appendItemAction); ok {
+					*state.owner = "other"
+				}
+				return state, nil
+			},
+			wantPath:   "state.owner",
+			wantSource: MutationInReducer,
+			wantIndex:  1,
+		},
+		{
+			name: "subscriber mutates a slice",
+			subscriber: func(newState listState, oldState listState, action Action) {
+				if _, ok := action.(*appendItemAction); ok {
+					oldState.items[0] = "z"
+				}
+			},
+			wantPath:   "state.items[0]",
+			wantSource: MutationInSubscriber,
+			wantIndex:  -1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			owner = "owner"
+			initialState.items[0] = "a"
+			initialState.tags = map[string]int{"a": 1}
+
+			b := NewStore(initialState, listReducer).SetMutationCheck(true)
+			if tt.reducer != nil {
+				b.AddReducer(tt.reducer)
+			}
+			if tt.subscriber != nil {
+				b.Subscribe(tt.subscriber)
+			}
+
+			var lock sync.Mutex
+			var reported []*ReducerError
+			b.OnError(func(err *ReducerError) {
+				lock.Lock()
+				defer lock.Unlock()
+				reported = append(reported, err)
+			})
+
+			b.Dispatch(&appendItemAction{"b"})
+			b.Stop()
+			b.WaitForStore()
+
+			lock.Lock()
+			defer lock.Unlock()
+			if tt.wantPath == "" {
+				if len(reported) != 0 {
+					t.Errorf("SetMutationCheck: want no error got %v", reported)
+				}
+				return
+			}
+			if len(reported) != 1 {
+				t.Fatalf("SetMutationCheck: want 1 error got %v", reported)
+			}
+			var mutated *MutationError
+			if !errors.As(reported[0], &mutated) || !errors.Is(reported[0], ErrMutation) {
+				t.Fatalf("SetMutationCheck: want MutationError got %v", reported[0])
+			}
+			if mutated.Path != tt.wantPath || mutated.Source != tt.wantSource || reported[0].Reducer != tt.wantIndex {
+				t.Errorf("SetMutationCheck: want %s %s %d got %v", tt.wantSource, tt.wantPath, tt.wantIndex, reported[0])
+			}
+		})
+	}
+}
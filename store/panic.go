@@ -0,0 +1,48 @@
+package store
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/rookiecj/go-store/logger"
+)
+
+// defaultSubscriberPanicLimit is the number of panics a subscriber is unsubscribed after
+const defaultSubscriberPanicLimit = 3
+
+// where a panic is recovered
+const (
+	PanicInReducer     = "reducer"
+	PanicInSubscriber  = "subscriber"
+	PanicInMiddleware  = "middleware"
+	PanicInThunk       = "thunk"
+	PanicInTransaction = "transaction"
+)
+
+// PanicError is a panic recovered from a reducer, a subscriber, a middleware or a thunk.
+type PanicError struct {
+	// Source is where the panic is recovered, PanicInReducer, PanicInSubscriber, PanicInMiddleware, PanicInThunk or PanicInTransaction
+	Source string
+	Action Action
+	// Value is the value passed to panic
+	Value any
+	Stack []byte
+}
+
+func (c *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: action %T: %v", c.Source, c.Action, c.Value)
+}
+
+func newPanicError(source string, action Action, recovered any) *PanicError {
+	return &PanicError{
+		Source: source,
+		Action: action,
+		Value:  recovered,
+		Stack:  debug.Stack(),
+	}
+}
+
+// logPanic is the default panic handler
+func logPanic(err *PanicError) {
+	logger.LogForcedf("Store: %s\n%s", err, err.Stack)
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_baseStore_Panic(t *testing.T) {
+
+	t.Run("reducer", func(t *testing.T) {
+		b := newMyStateStore()
+		b.AddReducer(func(state myState, action Action) (myState, error) {
+			if _, ok := action.(*failAction); ok {
+				panic("reducer panics")
+			}
+			return state, nil
+		})
+
+		var reported []*ReducerError
+		b.OnError(func(err *ReducerError) {
+			reported = append(reported, err)
+		})
+
+		b.Dispatch(&addAction{"1"})
+		_, _, err := b.DispatchSync(context.Background(), &failAction{"x"})
+		b.Dispatch(&addAction{"2"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) || panicErr.Source != PanicInReducer || len(panicErr.Stack) == 0 {
+			t.Errorf("Panic: want PanicError got %v", err)
+		}
+		if len(reported) != 1 || reported[0].Reducer != 1 {
+			t.Errorf("Panic: reported %v", reported)
+		}
+		assertState(t, b.GetState(), myState{value: "12"}, nil)
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		b := newMyStateStore()
+		ApplyMiddleware(b, func(api MiddlewareAPI[myState], next DispatchFunc, action Action) {
+			if _, ok := action.(*failAction); ok {
+				panic("middleware panics")
+			}
+			next(action)
+		})
+
+		var handled []*PanicError
+		b.SetPanicHandler(func(err *PanicError) {
+			handled = append(handled, err)
+		})
+
+		_, _, err := b.DispatchSync(context.Background(), &failAction{"x"})
+		state, _, _ := b.DispatchSync(context.Background(), &addAction{"1"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) || panicErr.Source != PanicInMiddleware {
+			t.Errorf("Panic: want PanicError got %v", err)
+		}
+		if len(handled) != 1 {
+			t.Errorf("Panic: handled %v", handled)
+		}
+		assertState(t, state, myState{value: "1"}, nil)
+	})
+
+	t.Run("subscribers unsubscribed after the limit", func(t *testing.T) {
+		b := newMyStateStore()
+		b.SetSubscriberPanicLimit(2)
+
+		lock := sync.Mutex{}
+		var handled []*PanicError
+		b.SetPanicHandler(func(err *PanicError) {
+			lock.Lock()
+			handled = append(handled, err)
+			lock.Unlock()
+		})
+
+		var panicking int
+		b.SubscribeOn(sched.Background, func(newState myState, oldState myState, action Action) {
+			if _, ok := action.(*InitAction); ok {
+				return
+			}
+			panicking++
+			panic("subscriber panics")
+		})
+		var notified int
+		b.Subscribe(func(newState myState, oldState myState, action Action) {
+			notified++
+		})
+
+		for _, value := range []string{"1", "2", "3", "4"} {
+			b.Dispatch(&addAction{value})
+		}
+
+		b.Stop()
+		b.WaitForStore()
+
+		if panicking != 2 || len(handled) != 2 || handled[0].Source != PanicInSubscriber {
+			t.Errorf("Panic: panicking %d handled %v", panicking, handled)
+		}
+		if notified != 1+4 {
+			t.Errorf("Panic: notified want %d got %d", 1+4, notified)
+		}
+		if got := len(b.(*baseStore[myState]).subscribers); got != 1 {
+			t.Errorf("Panic: subscribers want %d got %d", 1, got)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_baseStore_ResetAction(t *testing.T) {
+
+	type args struct {
+		actions []Action
+	}
+	type testCaseReset[S State] struct {
+		name string
+		b    Store[S]
+		args args
+		want S
+	}
+
+	tests := []testCaseReset[myState]{
+		{
+			name: "reset to initial state",
+			b: NewStore(myState{
+				id:    1,
+				value: "initial",
+			}, myStateReducer),
+			args: args{
+				actions: []Action{
+					&addAction{"1"},
+					&ResetAction[myState]{},
+				},
+			},
+			want: myState{
+				id:    1,
+				value: "initial",
+			},
+		},
+		{
+			name: "reset to the given state",
+			b:    newMyStateStore(),
+			args: args{
+				actions: []Action{
+					&addAction{"1"},
+					NewResetAction(myState{id: 2, value: "reset"}),
+					&addAction{"2"},
+				},
+			},
+			want: myState{
+				id:    2,
+				value: "reset2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var notified []Action
+			tt.b.Subscribe(func(newState myState, oldState myState, action Action) {
+				notified = append(notified, action)
+			})
+
+			for _, action := range tt.args.actions {
+				tt.b.Dispatch(action)
+			}
+
+			tt.b.Stop()
+			tt.b.WaitForStore()
+
+			assertState(t, tt.b.GetState(), tt.want, tt.args.actions)
+			// InitAction when subscribe
+			if len(notified) != 1+len(tt.args.actions) {
+				t.Errorf("ResetAction: notified want %d got %d", 1+len(tt.args.actions), len(notified))
+			}
+		})
+	}
+
+	t.Run("reset bypasses reducers", func(t *testing.T) {
+		var reduced []Action
+		b := newMyStateStore()
+		b.AddReducer(func(state myState, action Action) (myState, error) {
+			reduced = append(reduced, action)
+			return state, nil
+		})
+
+		b.Dispatch(&addAction{"1"})
+		b.Dispatch(&ResetAction[myState]{})
+
+		b.Stop()
+		b.WaitForStore()
+
+		if want := []Action{&addAction{"1"}}; !reflect.DeepEqual(want, reduced) {
+			t.Errorf("ResetAction: reduced want %v got %v", want, reduced)
+		}
+	})
+}
+
+func Test_baseStore_InitAction(t *testing.T) {
+
+	t.Run("reducers get InitAction when the store is created", func(t *testing.T) {
+		lazyReducer := func(state myState, action Action) (myState, error) {
+			switch action.(type) {
+			case *InitAction:
+				if state.value == "" {
+					return myState{id: state.id, value: "lazy"}, nil
+				}
+			}
+			return myStateReducer(state, action)
+		}
+
+		b := NewStore(myInitialState, lazyReducer)
+		b.Dispatch(&addAction{"1"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "lazy1"}, nil)
+	})
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+// Selector selects a part of the state.
+type Selector[S State, T any] func(state S) T
+
+// SelectSubscriber is notified when the selected part of the state changes.
+type SelectSubscriber[T any] func(newValue T, oldValue T, action Action)
+
+// memo remembers the last inputs and the result, it can be used in any context
+type memo[I any, T any] struct {
+	lock   sync.Mutex
+	equal  func(a, b I) bool
+	valid  bool
+	input  I
+	result T
+}
+
+func (c *memo[I, T]) get(input I, compute func() T) T {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.valid || !c.equal(c.input, input) {
+		c.input = input
+		c.result = compute()
+		c.valid = true
+	}
+	return c.result
+}
+
+// Identical reports whether a and b are the same by ==, except the slices and the maps by the pointer and the length,
+// the functions are not identical unless nil. it is used to compare the inputs of the selectors.
+func Identical[T any](a, b T) bool {
+	return identical(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+}
+
+func identical(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Slice, reflect.Map:
+		return a.IsNil() == b.IsNil() && a.Pointer() == b.Pointer() && a.Len() == b.Len()
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return a.Elem().Type() == b.Elem().Type() && identical(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for idx := 0; idx < a.NumField(); idx++ {
+			if !identical(a.Field(idx), b.Field(idx)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for idx := 0; idx < a.Len(); idx++ {
+			if !identical(a.Index(idx), b.Index(idx)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// CreateSelector1 creates a memoized selector which calls combine only when the input changes.
+// the inputs are compared by Identical, use CreateSelectorFunc to compare by own.
+func CreateSelector1[S State, I1 any, T any](input1 Selector[S, I1], combine func(I1) T) Selector[S, T] {
+	return CreateSelectorFunc(input1, Identical[I1], combine)
+}
+
+// CreateSelectorFunc creates a memoized selector which calls combine only when the input changes by equal.
+func CreateSelectorFunc[S State, I any, T any](input Selector[S, I], equal func(a, b I) bool, combine func(I) T) Selector[S, T] {
+	cache := &memo[I, T]{equal: equal}
+	return func(state S) T {
+		in := input(state)
+		return cache.get(in, func() T {
+			return combine(in)
+		})
+	}
+}
+
+// CreateSelector2 creates a memoized selector which calls combine only when any of inputs changes.
+func CreateSelector2[S State, I1, I2 any, T any](input1 Selector[S, I1], input2 Selector[S, I2], combine func(I1, I2) T) Selector[S, T] {
+	type inputs struct {
+		in1 I1
+		in2 I2
+	}
+	cache := &memo[inputs, T]{equal: func(a, b inputs) bool {
+		return Identical(a.in1, b.in1) && Identical(a.in2, b.in2)
+	}}
+	return func(state S) T {
+		in := inputs{input1(state), input2(state)}
+		return cache.get(in, func() T {
+			return combine(in.in1, in.in2)
+		})
+	}
+}
+
+// CreateSelector3 creates a memoized selector which calls combine only when any of inputs changes.
+func CreateSelector3[S State, I1, I2, I3 any, T any](input1 Selector[S, I1], input2 Selector[S, I2], input3 Selector[S, I3], combine func(I1, I2, I3) T) Selector[S, T] {
+	type inputs struct {
+		in1 I1
+		in2 I2
+		in3 I3
+	}
+	cache := &memo[inputs, T]{equal: func(a, b inputs) bool {
+		return Identical(a.in1, b.in1) && Identical(a.in2, b.in2) && Identical(a.in3, b.in3)
+	}}
+	return func(state S) T {
+		in := inputs{input1(state), input2(state), input3(state)}
+		return cache.get(in, func() T {
+			return combine(in.in1, in.in2, in.in3)
+		})
+	}
+}
+
+// SubscribeSelect adds a subscriber which is notified only when the selected value changes.
+// equal compares selected values, reflect.DeepEqual is used if nil
+func SubscribeSelect[S State, T any](store Store[S], selector Selector[S, T], equal func(a, b T) bool, subscriber SelectSubscriber[T]) Disposer {
+	return SubscribeSelectOn(store, nil, selector, equal, subscriber)
+}
+
+// SubscribeSelectOn adds a subscriber which is notified on the scheduler only when the selected value changes.
+// selector is called on the scheduler too.
+func SubscribeSelectOn[S State, T any](store Store[S], scheduler sched.Scheduler, selector Selector[S, T], equal func(a, b T) bool, subscriber SelectSubscriber[T]) Disposer {
+	if store == nil {
+		return nil
+	}
+	if equal == nil {
+		equal = func(a, b T) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+
+	// subscriber is notified one by one, no need to lock
+	var last T
+	selected := false
+	return store.SubscribeOn(scheduler, func(newState S, oldState S, action Action) {
+		value := selector(newState)
+		if !selected {
+			// the first notification delivers the current value
+			selected = true
+			last = value
+			subscriber(value, value, action)
+			return
+		}
+		if equal(last, value) {
+			return
+		}
+		old := last
+		last = value
+		subscriber(value, old, action)
+	})
+}
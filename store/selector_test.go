@@ -0,0 +1,178 @@
+package store
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_CreateSelector(t *testing.T) {
+
+	t.Run("memoized by inputs", func(t *testing.T) {
+		var computed int
+		selectValue := func(state myState) string { return state.value }
+		selectID := func(state myState) int { return state.id }
+
+		selector := CreateSelector2(selectValue, selectID, func(value string, id int) []string {
+			computed++
+			return []string{value, value}
+		})
+
+		got := selector(myState{id: 1, value: "a"})
+		got = selector(myState{id: 1, value: "a"})
+		if computed != 1 {
+			t.Errorf("CreateSelector2: computed want %d got %d", 1, computed)
+		}
+		if !reflect.DeepEqual([]string{"a", "a"}, got) {
+			t.Errorf("CreateSelector2: got %v", got)
+		}
+
+		selector(myState{id: 2, value: "a"})
+		if computed != 2 {
+			t.Errorf("CreateSelector2: computed want %d got %d", 2, computed)
+		}
+	})
+
+	t.Run("composed", func(t *testing.T) {
+		var computed int
+		length := CreateSelector1(func(state myState) string { return state.value }, func(value string) int {
+			computed++
+			return len(value)
+		})
+		even := CreateSelector1(length, func(n int) bool {
+			return n%2 == 0
+		})
+
+		if even(myState{value: "ab"}) != true || even(myState{value: "abc"}) != false {
+			t.Errorf("CreateSelector1: composed got wrong value")
+		}
+		even(myState{value: "abc"})
+		if computed != 2 {
+			t.Errorf("CreateSelector1: computed want %d got %d", 2, computed)
+		}
+	})
+}
+
+func Test_CreateSelector_Identical(t *testing.T) {
+	var computed int
+	selectItems := func(state listState) []string { return state.items }
+	selectTags := func(state listState) map[string]int { return state.tags }
+	count := CreateSelector2(selectItems, selectTags, func(items []string, tags map[string]int) int {
+		computed++
+		return len(items) + len(tags)
+	})
+
+	state := listState{items: []string{"a"}, tags: map[string]int{"a": 1}}
+	count(state)
+	// copied, not changed
+	copied := state
+	count(copied)
+	if computed != 1 {
+		t.Errorf("CreateSelector2: computed want %d got %d", 1, computed)
+	}
+
+	state, _ = listReducer(state, &appendItemAction{"b"})
+	if got := count(state); got != 3 || computed != 2 {
+		t.Errorf("CreateSelector2: want 3 computed 2 got %d computed %d", got, computed)
+	}
+
+	byLength := CreateSelectorFunc(selectItems, func(a, b []string) bool {
+		return len(a) == len(b)
+	}, func(items []string) string {
+		return items[0]
+	})
+	byLength(listState{items: []string{"a"}})
+	if got := byLength(listState{items: []string{"b"}}); got != "a" {
+		t.Errorf("CreateSelectorFunc: want memoized a got %s", got)
+	}
+}
+
+func Test_SubscribeSelect(t *testing.T) {
+
+	type args struct {
+		scheduler sched.Scheduler
+		actions   []Action
+	}
+	type testCaseSubscribeSelect[S State] struct {
+		name string
+		b    Store[S]
+		args args
+		want []int // selected values notified
+	}
+
+	tests := []testCaseSubscribeSelect[myState]{
+		{
+			name: "no actions - current value",
+			b:    newMyStateStore(),
+			args: args{},
+			want: []int{0},
+		},
+		{
+			name: "unit actions - no change",
+			b:    newMyStateStore(),
+			args: args{
+				actions: []Action{&UnitAction{}, &UnitAction{}},
+			},
+			want: []int{0},
+		},
+		{
+			name: "notified when selected value changed",
+			b:    newMyStateStore(),
+			args: args{
+				actions: []Action{
+					&addAction{"1"},
+					&setAction{"2"},
+					&addAction{"2"},
+					&UnitAction{},
+					&setAction{"123"},
+				},
+			},
+			want: []int{0, 1, 2, 3},
+		},
+		{
+			name: "background",
+			b:    newMyStateStore(),
+			args: args{
+				scheduler: sched.Background,
+				actions: []Action{
+					&addAction{"1"},
+					&setAction{"2"},
+					&addAction{"2"},
+				},
+			},
+			want: []int{0, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			var lastOld int64 = -1
+			SubscribeSelectOn(tt.b, tt.args.scheduler, func(state myState) int {
+				return len(state.value)
+			}, func(a, b int) bool {
+				return a == b
+			}, func(newValue int, oldValue int, action Action) {
+				if len(got) > 0 && int64(oldValue) != atomic.LoadInt64(&lastOld) {
+					t.Errorf("SubscribeSelect: old value want %d got %d", lastOld, oldValue)
+				}
+				atomic.StoreInt64(&lastOld, int64(newValue))
+				got = append(got, newValue)
+			})
+
+			for _, action := range tt.args.actions {
+				tt.b.Dispatch(action)
+			}
+
+			tt.b.Stop()
+			tt.b.WaitForStore()
+
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("SubscribeSelect: want %v got %v", tt.want, got)
+			}
+		})
+	}
+}
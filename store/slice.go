@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+// Lens focuses on a part T of the state S.
+// Set should return a new S, not modify the given one.
+type Lens[S any, T any] struct {
+	Get func(state S) T
+	Set func(state S, value T) S
+}
+
+// Slice is a part of the state reduced by its own reducer.
+type Slice[S State, T State] struct {
+	Name    string
+	Lens    Lens[S, T]
+	Reducer Reducer[T]
+	// Equal reports whether two values of the slice are the same, reflect.DeepEqual is used if nil
+	Equal func(a, b T) bool
+}
+
+// SliceReducer is a Slice regardless of the type of the part.
+type SliceReducer[S State] interface {
+	// SliceName returns the name of the slice.
+	SliceName() string
+
+	// Changed reports whether the slice differs between two states.
+	Changed(newState S, oldState S) bool
+
+	// reduceSlice reduces the slice of the state
+	reduceSlice(state S, action Action) (S, error)
+}
+
+// NewSlice creates a slice with a lens and a reducer for the part.
+func NewSlice[S State, T State](name string, lens Lens[S, T], reducer Reducer[T]) *Slice[S, T] {
+	return &Slice[S, T]{
+		Name:    name,
+		Lens:    lens,
+		Reducer: reducer,
+	}
+}
+
+func (c *Slice[S, T]) SliceName() string {
+	return c.Name
+}
+
+func (c *Slice[S, T]) Changed(newState S, oldState S) bool {
+	return !c.equal(c.Lens.Get(newState), c.Lens.Get(oldState))
+}
+
+// Select returns a selector for the slice.
+func (c *Slice[S, T]) Select() Selector[S, T] {
+	return c.Lens.Get
+}
+
+func (c *Slice[S, T]) equal(a, b T) bool {
+	if c.Equal != nil {
+		return c.Equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// reduceSlice sets the part back, not compared not to walk the part for every action.
+// Changed compares it only for the subscribers asking.
+func (c *Slice[S, T]) reduceSlice(state S, action Action) (S, error) {
+	newPart, err := c.Reducer(c.Lens.Get(state), action)
+	return c.Lens.Set(state, newPart), err
+}
+
+// CombineSlices creates a reducer which reduces each slice with its own reducer in order.
+// ErrSkipReducing from a slice stops reducing the rest slices and is returned as is,
+// the first other error is returned after reducing all slices.
+func CombineSlices[S State](slices ...SliceReducer[S]) Reducer[S] {
+	return func(state S, action Action) (S, error) {
+		var firstErr error
+		for _, slice := range slices {
+			var err error
+			state, err = slice.reduceSlice(state, action)
+			if err != nil {
+				if errors.Is(err, ErrSkipReducing) {
+					return state, err
+				}
+				if firstErr == nil {
+					firstErr = fmt.Errorf("slice %s: %w", slice.SliceName(), err)
+				}
+			}
+		}
+		return state, firstErr
+	}
+}
+
+// ChangedSlices returns the names of the slices changed between two states.
+func ChangedSlices[S State](newState S, oldState S, slices ...SliceReducer[S]) (names []string) {
+	for _, slice := range slices {
+		if slice.Changed(newState, oldState) {
+			names = append(names, slice.SliceName())
+		}
+	}
+	return
+}
+
+// SubscribeSlice adds a subscriber which is notified only when the slice changes.
+func SubscribeSlice[S State, T State](store Store[S], slice *Slice[S, T], subscriber SelectSubscriber[T]) Disposer {
+	return SubscribeSliceOn(store, nil, slice, subscriber)
+}
+
+// SubscribeSliceOn adds a subscriber which is notified on the scheduler only when the slice changes.
+func SubscribeSliceOn[S State, T State](store Store[S], scheduler sched.Scheduler, slice *Slice[S, T], subscriber SelectSubscriber[T]) Disposer {
+	return SubscribeSelectOn(store, scheduler, slice.Select(), slice.equal, subscriber)
+}
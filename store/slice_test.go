@@ -0,0 +1,174 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type counterState struct {
+	count int
+}
+
+func (c counterState) StateInterface() {}
+
+type incrementAction struct{}
+
+type appState struct {
+	counter counterState
+	my      myState
+}
+
+func (c appState) StateInterface() {}
+
+var errCounterOverflow = errors.New("counter overflow")
+
+func counterReducer(state counterState, action Action) (counterState, error) {
+	switch action.(type) {
+	case *incrementAction:
+		if state.count >= 2 {
+			return state, errCounterOverflow
+		}
+		return counterState{count: state.count + 1}, nil
+	}
+	return state, nil
+}
+
+var (
+	counterSlice = NewSlice("counter", Lens[appState, counterState]{
+		Get: func(state appState) counterState { return state.counter },
+		Set: func(state appState, value counterState) appState {
+			state.counter = value
+			return state
+		},
+	}, counterReducer)
+
+	mySlice = NewSlice("my", Lens[appState, myState]{
+		Get: func(state appState) myState { return state.my },
+		Set: func(state appState, value myState) appState {
+			state.my = value
+			return state
+		},
+	}, myStateReducer)
+)
+
+func Test_CombineSlices(t *testing.T) {
+
+	type args struct {
+		actions []Action
+	}
+	type testCaseCombineSlices[S State] struct {
+		name        string
+		args        args
+		want        S
+		wantChanged [][]string // changed slices for each action
+		wantErr     error
+	}
+
+	tests := []testCaseCombineSlices[appState]{
+		{
+			name: "each slice reduced by its own reducer",
+			args: args{
+				actions: []Action{
+					&incrementAction{},
+					&addAction{"1"},
+					&UnitAction{},
+				},
+			},
+			want: appState{
+				counter: counterState{count: 1},
+				my:      myState{value: "1"},
+			},
+			wantChanged: [][]string{{"counter"}, {"my"}, nil},
+		},
+		{
+			name: "error from a slice",
+			args: args{
+				actions: []Action{
+					&incrementAction{},
+					&incrementAction{},
+					&incrementAction{},
+				},
+			},
+			want: appState{
+				counter: counterState{count: 2},
+			},
+			wantChanged: [][]string{{"counter"}, {"counter"}, nil},
+			wantErr:     errCounterOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			reducer := CombineSlices[appState](counterSlice, mySlice)
+
+			var err error
+			var gotChanged [][]string
+			state := appState{}
+			for _, action := range tt.args.actions {
+				var newState appState
+				newState, err = reducer(state, action)
+				gotChanged = append(gotChanged, ChangedSlices[appState](newState, state, counterSlice, mySlice))
+				state = newState
+			}
+
+			if !reflect.DeepEqual(tt.want, state) {
+				t.Errorf("CombineSlices: want %v got %v", tt.want, state)
+			}
+			if !reflect.DeepEqual(tt.wantChanged, gotChanged) {
+				t.Errorf("ChangedSlices: want %v got %v", tt.wantChanged, gotChanged)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CombineSlices: error want %v got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_CombineSlices_NotCompared(t *testing.T) {
+	compared := 0
+	slice := NewSlice("counter", counterSlice.Lens, counterReducer)
+	slice.Equal = func(a, b counterState) bool {
+		compared++
+		return a == b
+	}
+
+	reducer := CombineSlices[appState](slice)
+	state, _ := reducer(appState{}, &incrementAction{})
+	state, _ = reducer(state, &UnitAction{})
+	if state.counter.count != 1 || compared != 0 {
+		t.Errorf("CombineSlices: want count 1 not compared got %d compared %d", state.counter.count, compared)
+	}
+}
+
+func Test_SubscribeSlice(t *testing.T) {
+
+	t.Run("notified when the slice changed", func(t *testing.T) {
+		b := NewStore(appState{}, CombineSlices[appState](counterSlice, mySlice))
+
+		var counters []int
+		SubscribeSlice(b, counterSlice, func(newValue counterState, oldValue counterState, action Action) {
+			counters = append(counters, newValue.count)
+		})
+		var values []string
+		SubscribeSlice(b, mySlice, func(newValue myState, oldValue myState, action Action) {
+			values = append(values, newValue.value)
+		})
+
+		b.Dispatch(&addAction{"1"})
+		b.Dispatch(&incrementAction{})
+		b.Dispatch(&addAction{"2"})
+		b.Dispatch(&incrementAction{})
+
+		b.Stop()
+		b.WaitForStore()
+
+		if want := []int{0, 1, 2}; !reflect.DeepEqual(want, counters) {
+			t.Errorf("SubscribeSlice: want %v got %v", want, counters)
+		}
+		if want := []string{"", "1", "12"}; !reflect.DeepEqual(want, values) {
+			t.Errorf("SubscribeSlice: want %v got %v", want, values)
+		}
+	})
+}
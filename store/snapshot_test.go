@@ -0,0 +1,107 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func Test_baseStore_Snapshot(t *testing.T) {
+
+	type args struct {
+		actions []Action
+	}
+	type testCaseSnapshot[S State] struct {
+		name    string
+		b       Store[S]
+		args    args
+		want    S
+		wantAge int64
+	}
+	tests := []testCaseSnapshot[myState]{
+		{
+			name:    "no actions - InitAction reduced",
+			b:       newMyStateStore(),
+			args:    args{},
+			want:    myInitialState,
+			wantAge: 1,
+		},
+		{
+			name: "age increased by actions even not changing state",
+			b:    newMyStateStore(),
+			args: args{
+				actions: []Action{
+					&addAction{"1"},
+					&UnitAction{},
+					&addAction{"2"},
+				},
+			},
+			want: myState{
+				id:    0,
+				value: "12",
+			},
+			wantAge: 1 + 3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range tt.args.actions {
+				tt.b.Dispatch(action)
+			}
+
+			tt.b.Stop()
+			tt.b.WaitForStore()
+
+			got, gotAge := tt.b.Snapshot()
+			assertState(t, got, tt.want, tt.args.actions)
+			if tt.wantAge != gotAge {
+				t.Errorf("Snapshot: age want %d got %d", tt.wantAge, gotAge)
+			}
+		})
+	}
+}
+
+func Test_baseStore_GetState_Concurrent(t *testing.T) {
+
+	t.Run("read state while dispatching", func(t *testing.T) {
+		b := newMyStateStore()
+
+		limit := 1024
+		readers := 8
+
+		wg := sync.WaitGroup{}
+		wg.Add(readers)
+		for idx := 0; idx < readers; idx++ {
+			go func() {
+				defer wg.Done()
+				var lastAge int64
+				for {
+					state, age := b.Snapshot()
+					if age < lastAge {
+						t.Errorf("Snapshot: age goes back %d -> %d", lastAge, age)
+						return
+					}
+					// InitAction is the first
+					if age > 0 && int64(len(state.value))+1 != age {
+						t.Errorf("Snapshot: state %v does not match age %d", state, age)
+						return
+					}
+					lastAge = age
+					if age == int64(limit)+1 {
+						return
+					}
+				}
+			}()
+		}
+
+		for idx := 0; idx < limit; idx++ {
+			b.Dispatch(&addAction{fmt.Sprintf("%d", idx%10)})
+		}
+
+		wg.Wait()
+		b.Stop()
+		b.WaitForStore()
+	})
+}
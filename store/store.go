@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"errors"
 
 	"github.com/rookiecj/go-store/sched"
@@ -9,17 +10,91 @@ import (
 var (
 	// ErrSkipReducing is returned by a reducer to stop reducing further.
 	ErrSkipReducing = errors.New("skip reducing")
+
+	// ErrStoreStopped is returned when an action is dispatched after the store stopped.
+	ErrStoreStopped = errors.New("store stopped")
+)
+
+// Status is the lifecycle of the store, running -> stopping -> stopped
+type Status int32
+
+const (
+	// StatusRunning accepts actions
+	StatusRunning Status = iota
+	// StatusStopping rejects actions, reduces the actions accepted before
+	StatusStopping
+	// StatusStopped reduced all the actions accepted
+	StatusStopped
 )
 
+func (c Status) String() string {
+	switch c {
+	case StatusRunning:
+		return "running"
+	case StatusStopping:
+		return "stopping"
+	case StatusStopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
 // Store holds the state of the application.
 type Store[S State] interface {
 
 	// AddReducer adds a reducer to the store.
 	AddReducer(reducer Reducer[S]) Store[S]
 
+	// SetErrorPolicy sets what to do when a reducer returns an error, ContinueOnError by default.
+	SetErrorPolicy(policy ErrorPolicy) Store[S]
+
+	// OnError adds a handler called with an error from reducers in the dispatch context.
+	OnError(handler func(err *ReducerError)) Disposer
+
+	// Errors returns the errors from reducers, the errors are dropped if not read in time.
+	// it is closed when the store stopped.
+	Errors() <-chan *ReducerError
+
+	// SetPanicHandler sets the handler for panics recovered from subscribers and middlewares,
+	// panics from reducers are reported as ReducerError. the default handler logs the panic with the stack trace.
+	SetPanicHandler(handler func(err *PanicError)) Store[S]
+
+	// SetSubscriberPanicLimit sets the number of panics a subscriber is unsubscribed after, 0 to keep subscribers.
+	SetSubscriberPanicLimit(limit int) Store[S]
+
+	// SetMutationCheck enables to check reducers and subscribers not to mutate the states given, for debugging.
+	// a mutation is reported as ReducerError with MutationError, the state is walked deeply before and after each call.
+	SetMutationCheck(enabled bool) Store[S]
+
 	// Dispatch dispatches an action to the store.
+	// the error of TryDispatch is logged
 	Dispatch(action Action)
 
+	// Transaction calls fn in the dispatch context, the actions dispatched by tx are reduced against a working copy.
+	// they are committed as a BatchAction if fn returns nil, or rolled back if fn or a reducer returns an error.
+	// the actions by tx do not pass middlewares, the BatchAction committed does.
+	// it should not be called in the dispatch context, which never returns.
+	Transaction(fn func(tx Dispatcher) error) error
+
+	// DispatchOptimistic dispatches an action to be confirmed or reverted later,
+	// the actions dispatched after it are reduced again when it is reverted.
+	// up to 1024 actions are kept while updates are pending, the oldest pending one is confirmed if more.
+	DispatchOptimistic(action Action) OptimisticUpdate
+
+	// DispatchBatch dispatches the actions as a BatchAction,
+	// they are reduced at once and subscribers are notified once with the BatchAction.
+	DispatchBatch(actions ...Action)
+
+	// TryDispatch dispatches an action to the store,
+	// returns ErrStoreStopped after Stop, or the error of the scheduler like sched.ErrQueueFull
+	TryDispatch(action Action) error
+
+	// DispatchSync dispatches an action and waits until it is reduced and all subscribers are notified,
+	// returns the state and the age after the action.
+	// if ctx is done before the action is dequeued, the action is dropped.
+	// it should not be called in the dispatch context like in a subscriber, which never returns.
+	DispatchSync(ctx context.Context, action Action) (S, int64, error)
+
 	// Subscribe adds a subscriber to the store.
 	// subscribers are notified when the state changes.
 	Subscribe(subscriber Subscriber[S]) Disposer
@@ -28,6 +103,15 @@ type Store[S State] interface {
 	// when the state changes, subscribers are notified on the scheduler.
 	SubscribeOn(scheduler sched.Scheduler, subscriber Subscriber[S]) Disposer
 
+	// SubscribeContext adds a subscriber notified on the scheduler,
+	// it is disposed when ctx is done.
+	SubscribeContext(ctx context.Context, scheduler sched.Scheduler, subscriber Subscriber[S]) Disposer
+
+	// Changes returns a channel of the changes starting with InitAction,
+	// bufferSize changes are buffered and the policy decides what to do when it is full.
+	// the channel is closed when ctx is done or the store stopped.
+	Changes(ctx context.Context, bufferSize int, policy OverflowPolicy) <-chan Change[S]
+
 	//// idle -> close model
 	//// WaitForIdle waits for idle
 	//WaitForIdle()
@@ -37,13 +121,36 @@ type Store[S State] interface {
 	// stop -> wait model
 
 	// Stop stops the store
+	// the actions dispatched before are reduced, the actions after are rejected.
+	// the thunks running are cancelled.
 	Stop()
 
-	// WaitForStore waits for the store to stop, optionally can wait the store
+	// WaitForStore waits for the store to stop and the thunks running to return, optionally can wait the store
 	WaitForStore()
 
-	// getState returns the current state of the store.
-	getState() S
+	// Status returns the lifecycle of the store.
+	Status() Status
+
+	// Done is closed when the store stopped.
+	Done() <-chan struct{}
+
+	// GetState returns the current state of the store.
+	// it is safe to call from any goroutine.
+	GetState() S
+
+	// Envelope returns the envelope of the action being reduced or notified,
+	// it is valid in reducers, middlewares and subscribers while they are called.
+	Envelope() *Envelope
+
+	// Snapshot returns the current state with its age,
+	// the age is increased whenever an action is reduced.
+	Snapshot() (state S, age int64)
+
+	// applyMiddleware appends middlewares to the dispatch chain.
+	applyMiddleware(middlewares ...Middleware[S])
+
+	// goThunk runs a thunk on the scheduler with the store context.
+	goThunk(scheduler sched.Scheduler, run func(ctx context.Context) error, done func(err error)) (context.CancelFunc, error)
 }
 
 // State is value class
@@ -58,6 +165,13 @@ type Reducer[S State] func(state S, action Action) (S, error)
 // Subscriber is notified when the state changes.
 type Subscriber[S State] func(newState S, oldState S, action Action)
 
+// Disposer disposes what it is created for, like a subscription.
 type Disposer interface {
+	// Dispose can be called more than once, and in the subscriber.
+	// the subscriber is not notified after Dispose returned, except the notification running.
 	Dispose()
+
+	// DisposeAndWait disposes and waits for the notification running to be done.
+	// it should not be called in the subscriber, which waits for itself.
+	DisposeAndWait()
 }
@@ -103,7 +103,7 @@ func Test_baseStore_Subscribe(t *testing.T) {
 			tt.b.WaitForStore()
 
 			if tt.want != tt.called {
-				t.Errorf("Subscribe: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.getState(), tt.args.action)
+				t.Errorf("Subscribe: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.GetState(), tt.args.action)
 			}
 		})
 	}
@@ -281,7 +281,7 @@ func Test_baseStore_SubscribeOn(t *testing.T) {
 			tt.b.WaitForStore()
 
 			if tt.want != tt.called {
-				t.Errorf("SubscribeOn: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.getState(), tt.args.action)
+				t.Errorf("SubscribeOn: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.GetState(), tt.args.action)
 			}
 		})
 	}
@@ -352,7 +352,7 @@ func Test_baseStore_SubscriberDispatchSerialized(t *testing.T) {
 			tt.b.WaitForStore()
 
 			if tt.want != tt.called {
-				t.Errorf("SubscribeOn: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.getState(), tt.args.action)
+				t.Errorf("SubscribeOn: want %d, got %d, state %v, action %v", tt.want, tt.called, tt.b.GetState(), tt.args.action)
 			}
 
 			wantCollected := func() (result string) {
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_baseStore_SubscribeContext(t *testing.T) {
+
+	t.Run("disposed when ctx cancelled", func(t *testing.T) {
+		b := newMyStateStore()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var notified atomic.Int32
+		b.SubscribeContext(ctx, nil, func(state myState, old myState, action Action) {
+			if _, ok := action.(*addAction); ok {
+				notified.Add(1)
+			}
+		})
+
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"1"}); err != nil {
+			t.Fatalf("DispatchSync: %v", err)
+		}
+		cancel()
+		// the disposing goroutine is not synchronized with the store
+		time.Sleep(50 * time.Millisecond)
+		if _, _, err := b.DispatchSync(context.Background(), &addAction{"2"}); err != nil {
+			t.Fatalf("DispatchSync: %v", err)
+		}
+
+		b.Stop()
+		b.WaitForStore()
+
+		if got := notified.Load(); got != 1 {
+			t.Errorf("notified: want 1 got %d", got)
+		}
+	})
+
+	t.Run("dispose twice", func(t *testing.T) {
+		b := newMyStateStore()
+		disposer := b.SubscribeContext(context.Background(), nil, func(state myState, old myState, action Action) {})
+		disposer.Dispose()
+		disposer.Dispose()
+		disposer.DisposeAndWait()
+
+		b.Stop()
+		b.WaitForStore()
+	})
+
+	t.Run("dispose in the subscriber", func(t *testing.T) {
+		b := newMyStateStore()
+
+		var notified atomic.Int32
+		var disposer Disposer
+		disposed := make(chan struct{})
+		disposer = b.Subscribe(func(state myState, old myState, action Action) {
+			if _, ok := action.(*addAction); ok {
+				notified.Add(1)
+				<-disposed
+				disposer.Dispose()
+			}
+		})
+		close(disposed)
+
+		b.Dispatch(&addAction{"1"})
+		b.Dispatch(&addAction{"2"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		if got := notified.Load(); got != 1 {
+			t.Errorf("notified: want 1 got %d", got)
+		}
+		assertState(t, b.GetState(), myState{value: "12"}, nil)
+	})
+
+	t.Run("wait for the notification running", func(t *testing.T) {
+		b := newMyStateStore()
+
+		started := make(chan struct{})
+		var finished atomic.Bool
+		disposer := b.SubscribeOn(sched.Background, func(state myState, old myState, action Action) {
+			if _, ok := action.(*addAction); ok {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+				finished.Store(true)
+			}
+		})
+
+		b.Dispatch(&addAction{"1"})
+		<-started
+		disposer.DisposeAndWait()
+		if !finished.Load() {
+			t.Errorf("DisposeAndWait: returned before the notification finished")
+		}
+
+		b.Stop()
+		b.WaitForStore()
+	})
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+// Thunk is async work with the store, run by RunThunk.
+// ctx is done when the thunk is cancelled or the store is stopping,
+// dispatch stamps the actions with the run of the thunk as the cause, correlated by it,
+// getState returns the latest state published.
+type Thunk[S State, R any] func(ctx context.Context, dispatch Dispatcher, getState func() S) (R, error)
+
+// Future is the result of a thunk.
+type Future[R any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	result R
+	err    error
+}
+
+func newFuture[R any]() *Future[R] {
+	return &Future[R]{
+		done: make(chan struct{}),
+	}
+}
+
+// Done is closed when the thunk returned.
+func (c *Future[R]) Done() <-chan struct{} {
+	return c.done
+}
+
+// Result waits for the thunk and returns its result.
+func (c *Future[R]) Result() (R, error) {
+	<-c.done
+	return c.result, c.err
+}
+
+// Wait waits for the thunk until ctx is done.
+func (c *Future[R]) Wait(ctx context.Context) (R, error) {
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// Cancel cancels the context of the thunk, the thunk should return on ctx done.
+func (c *Future[R]) Cancel() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *Future[R]) complete(result R, err error) {
+	c.result, c.err = result, err
+	close(c.done)
+}
+
+// RunThunk runs the thunk on the scheduler, sched.Background if nil.
+// WaitForStore waits for the thunks running, Stop cancels them.
+// a panic in the thunk is recovered and returned as *PanicError.
+func RunThunk[S State, R any](store Store[S], scheduler sched.Scheduler, thunk Thunk[S, R]) *Future[R] {
+	future := newFuture[R]()
+	if store == nil {
+		var zero R
+		future.complete(zero, ErrStoreStopped)
+		return future
+	}
+	if scheduler == nil {
+		scheduler = sched.Background
+	}
+
+	var result R
+	dispatch := &causedDispatcher{
+		dispatcher: store,
+		cause:      wrapAction(nil, nil),
+	}
+	cancel, err := store.goThunk(scheduler, func(ctx context.Context) error {
+		var err error
+		result, err = thunk(ctx, dispatch, store.GetState)
+		return err
+	}, func(err error) {
+		future.complete(result, err)
+	})
+	if err != nil {
+		var zero R
+		future.complete(zero, err)
+		return future
+	}
+	future.cancel = cancel
+	return future
+}
+
+// goThunk runs the thunk tracked with the store context, done is called with the error or the panic recovered
+func (b *baseStore[S]) goThunk(scheduler sched.Scheduler, run func(ctx context.Context) error, done func(err error)) (context.CancelFunc, error) {
+	// not to run after stopping, counted before WaitForStore waits for the thunks
+	if !b.accept(func() {
+		b.thunks.Add(1)
+	}) {
+		return nil, ErrStoreStopped
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	err := b.scheduleAccepted(scheduler, func() {
+		defer b.thunks.Done()
+		defer cancel()
+
+		var err error
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					panicErr := newPanicError(PanicInThunk, nil, recovered)
+					b.reportPanic(panicErr)
+					err = panicErr
+				}
+			}()
+			err = run(ctx)
+		}()
+		done(err)
+	})
+	if err != nil {
+		cancel()
+		b.thunks.Done()
+		return nil, err
+	}
+	return cancel, nil
+}
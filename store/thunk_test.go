@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rookiecj/go-store/sched"
+)
+
+func Test_RunThunk(t *testing.T) {
+
+	tests := []struct {
+		name string
+		// cancel the future after run
+		cancel bool
+		// stop the store after run
+		stop    bool
+		thunk   Thunk[myState, string]
+		want    string
+		wantErr error
+		state   myState
+	}{
+		{
+			name: "result",
+			thunk: func(ctx context.Context, dispatch Dispatcher, getState func() myState) (string, error) {
+				dispatch.Dispatch(&addAction{"1"})
+				return "done", nil
+			},
+			want:  "done",
+			state: myState{value: "1"},
+		},
+		{
+			name:   "cancelled",
+			cancel: true,
+			thunk: func(ctx context.Context, dispatch Dispatcher, getState func() myState) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "cancelled by Stop",
+			stop: true,
+			thunk: func(ctx context.Context, dispatch Dispatcher, getState func() myState) (string, error) {
+				<-ctx.Done()
+				// rejected
+				dispatch.Dispatch(&addAction{"1"})
+				return "", ctx.Err()
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "panic",
+			thunk: func(ctx context.Context, dispatch Dispatcher, getState func() myState) (string, error) {
+				panic("thunk panics")
+			},
+			wantErr: &PanicError{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMyStateStore()
+			b.SetPanicHandler(func(err *PanicError) {})
+
+			future := RunThunk(b, nil, tt.thunk)
+			if tt.cancel {
+				future.Cancel()
+			}
+			if tt.stop {
+				b.Stop()
+			}
+			got, err := future.Result()
+
+			b.Stop()
+			b.WaitForStore()
+
+			var panicErr *PanicError
+			if errors.As(tt.wantErr, &panicErr) {
+				if !errors.As(err, &panicErr) || panicErr.Source != PanicInThunk {
+					t.Errorf("Result: want PanicError got %v", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Result: want error %v got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Result: want %v got %v", tt.want, got)
+			}
+			assertState(t, b.GetState(), tt.state, nil)
+		})
+	}
+
+	t.Run("WaitForStore waits for thunks", func(t *testing.T) {
+		b := newMyStateStore()
+
+		var finished atomic.Bool
+		future := RunThunk(b, nil, func(ctx context.Context, dispatch Dispatcher, getState func() myState) (int, error) {
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(true)
+			return 1, nil
+		})
+
+		b.Stop()
+		b.WaitForStore()
+
+		if !finished.Load() {
+			t.Errorf("WaitForStore: returned before the thunk finished")
+		}
+		select {
+		case <-future.Done():
+		default:
+			t.Errorf("Done: want closed")
+		}
+	})
+
+	t.Run("after Stop", func(t *testing.T) {
+		b := newMyStateStore()
+		b.Stop()
+		b.WaitForStore()
+
+		future := RunThunk(b, nil, func(ctx context.Context, dispatch Dispatcher, getState func() myState) (int, error) {
+			return 1, nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := future.Wait(ctx); !errors.Is(err, ErrStoreStopped) {
+			t.Errorf("Wait: want %v got %v", ErrStoreStopped, err)
+		}
+	})
+
+	t.Run("Stop in a thunk on Immediate", func(t *testing.T) {
+		b := newMyStateStore()
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			RunThunk(b, sched.Immediate, func(ctx context.Context, dispatch Dispatcher, getState func() myState) (int, error) {
+				dispatch.Dispatch(&addAction{"1"})
+				b.Stop()
+				return 1, nil
+			})
+			b.WaitForStore()
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatalf("Stop: blocked in the thunk")
+		}
+		assertState(t, b.GetState(), myState{value: "1"}, nil)
+	})
+}
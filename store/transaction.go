@@ -0,0 +1,285 @@
+package store
+
+import (
+	"sync/atomic"
+
+	"github.com/rookiecj/go-store/logger"
+)
+
+// OptimisticUpdate is an action applied before its result is known, by DispatchOptimistic.
+type OptimisticUpdate interface {
+	// Confirm keeps the action applied.
+	Confirm()
+
+	// Revert removes the action, the actions dispatched after it are reduced again without it.
+	// subscribers are notified with RevertAction.
+	Revert()
+}
+
+// RevertAction is notified to subscribers when an optimistic update is reverted.
+type RevertAction struct {
+	Action Action
+}
+
+// txDispatcher reduces the actions against the working copy of the state
+type txDispatcher[S State] struct {
+	store   *baseStore[S]
+	state   S
+	age     int64
+	actions []Action
+	err     *ReducerError
+	closed  bool
+}
+
+func (c *txDispatcher[S]) Dispatch(action Action) {
+	if c.closed {
+		logger.Errf("Store: Transaction: action %T is dispatched after returned\n", action)
+		return
+	}
+	if c.err != nil {
+		// rolled back
+		return
+	}
+	if reified, ok := action.(*Envelope); ok && reified != nil {
+		action = reified.Action
+	}
+
+	state, err := c.store.reduceAction(c.state, action, c.age)
+	if err != nil && c.store.errorPolicy != ContinueOnError {
+		c.err = err
+		return
+	}
+	c.state = state
+	c.actions = append(c.actions, action)
+}
+
+func (b *baseStore[S]) Transaction(fn func(tx Dispatcher) error) error {
+	if b == nil {
+		return ErrStoreStopped
+	}
+
+	result := make(chan error, 1)
+	err := b.scheduleAction(b.dispatchScheduler, func() {
+		if b.halted {
+			result <- ErrStoreStopped
+			return
+		}
+
+		age := atomic.LoadInt64(&b.age)
+		tx := &txDispatcher[S]{
+			store: b,
+			state: b.state,
+			age:   age + 1,
+		}
+		err := b.callTransaction(fn, tx)
+		tx.closed = true
+		if err == nil && tx.err != nil {
+			err = tx.err
+			if b.errorPolicy == StopOnError {
+				b.halted = true
+				b.Stop()
+			}
+		}
+		if err != nil || len(tx.actions) == 0 {
+			// rolled back
+			result <- err
+			return
+		}
+
+		// committed as a batch through middlewares, not reduced again
+		b.runChain(wrapAction(&BatchAction{
+			Actions: tx.actions,
+			reduced: tx.state,
+			from:    age,
+		}, nil))
+		result <- b.reduceErr
+	})
+	if err != nil {
+		return err
+	}
+	return <-result
+}
+
+// callTransaction calls fn, a panic is returned as PanicError
+func (b *baseStore[S]) callTransaction(fn func(tx Dispatcher) error, tx Dispatcher) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := newPanicError(PanicInTransaction, nil, recovered)
+			b.reportPanic(panicErr)
+			err = panicErr
+		}
+	}()
+	return fn(tx)
+}
+
+// optimisticLimit is the number of the actions logged while optimistic updates are pending,
+// the oldest pending one is confirmed if more not to grow the log without limit.
+const optimisticLimit = 1024
+
+// optimisticLog is the actions reduced since the first optimistic update pending, in the dispatch context.
+// the state is confirmed reduced with entries.
+type optimisticLog[S State] struct {
+	confirmed S
+	entries   []*optimisticEntry[S]
+	// next is the update of the action passing through the chain
+	next *optimisticUpdate[S]
+	// replaying not to report errors again
+	replaying bool
+}
+
+type optimisticEntry[S State] struct {
+	action Action
+	// state after the action, not to reduce again when confirmed
+	state S
+	// update is nil if confirmed or not optimistic
+	update *optimisticUpdate[S]
+}
+
+type optimisticUpdate[S State] struct {
+	store   *baseStore[S]
+	settled atomic.Bool
+}
+
+func (c *optimisticUpdate[S]) Confirm() {
+	c.settle(c.store.confirmOptimistic)
+}
+
+func (c *optimisticUpdate[S]) Revert() {
+	c.settle(c.store.revertOptimistic)
+}
+
+// settle calls the first of Confirm or Revert in the dispatch context
+func (c *optimisticUpdate[S]) settle(settle func(update *optimisticUpdate[S])) {
+	if !c.settled.CompareAndSwap(false, true) {
+		return
+	}
+	if err := c.store.scheduleAction(c.store.dispatchScheduler, func() {
+		settle(c)
+	}); err != nil {
+		logger.Errf("Store: settle optimistic update: %s\n", err)
+	}
+}
+
+func (b *baseStore[S]) DispatchOptimistic(action Action) OptimisticUpdate {
+	if b == nil {
+		return nil
+	}
+
+	update := &optimisticUpdate[S]{store: b}
+	envelope := wrapAction(action, nil)
+	if err := b.scheduleAction(b.dispatchScheduler, func() {
+		b.optimistic.next = update
+		b.runChain(envelope)
+		// swallowed or discarded
+		b.optimistic.next = nil
+	}); err != nil {
+		logger.Errf("Store: dispatch optimistic %T: %s\n", action, err)
+		update.settled.Store(true)
+	}
+	return update
+}
+
+// recordOptimistic logs the action reduced while an optimistic update is pending
+func (b *baseStore[S]) recordOptimistic(oldState S, action Action, newState S) {
+	update := b.optimistic.next
+	b.optimistic.next = nil
+	if update == nil && len(b.optimistic.entries) == 0 {
+		return
+	}
+
+	if len(b.optimistic.entries) == 0 {
+		b.optimistic.confirmed = oldState
+	}
+	b.optimistic.entries = append(b.optimistic.entries, &optimisticEntry[S]{
+		action: action,
+		state:  newState,
+		update: update,
+	})
+
+	for len(b.optimistic.entries) > optimisticLimit {
+		oldest := b.optimistic.entries[0]
+		logger.Errf("Store: optimistic update %T is confirmed, pending for %d actions\n", oldest.action, optimisticLimit)
+		// Confirm and Revert are ignored after
+		oldest.update.settled.Store(true)
+		oldest.update = nil
+		b.foldOptimistic()
+	}
+}
+
+func (b *baseStore[S]) confirmOptimistic(update *optimisticUpdate[S]) {
+	for _, entry := range b.optimistic.entries {
+		if entry.update == update {
+			entry.update = nil
+			b.foldOptimistic()
+			return
+		}
+	}
+}
+
+func (b *baseStore[S]) revertOptimistic(update *optimisticUpdate[S]) {
+	if b.halted {
+		return
+	}
+
+	reverted := -1
+	for idx, entry := range b.optimistic.entries {
+		if entry.update == update {
+			reverted = idx
+			break
+		}
+	}
+	if reverted < 0 {
+		return
+	}
+
+	// the ones before are kept, the rest is rebased on the state before the reverted one
+	entries := b.optimistic.entries
+	state := b.optimistic.confirmed
+	if reverted > 0 {
+		state = entries[reverted-1].state
+	}
+	age := atomic.LoadInt64(&b.age) + 1
+	b.optimistic.entries = append([]*optimisticEntry[S](nil), entries[:reverted]...)
+	b.optimistic.replaying = true
+	for _, entry := range entries[reverted+1:] {
+		newState, err := b.reduceAction(state, entry.action, age)
+		if err != nil && b.errorPolicy != ContinueOnError {
+			// discarded on the new base
+			continue
+		}
+		state = newState
+		entry.state = newState
+		b.optimistic.entries = append(b.optimistic.entries, entry)
+	}
+	b.optimistic.replaying = false
+
+	action := &RevertAction{Action: entries[reverted].action}
+	oldState := b.state
+	b.state = state
+	atomic.StoreInt64(&b.age, age)
+	b.publish(state, age)
+	b.foldOptimistic()
+
+	b.envelope.Store(wrapAction(action, nil))
+	b.dispatch(age, oldState, action, state)
+}
+
+// foldOptimistic drops the entries before the first pending, the state after them is confirmed
+func (b *baseStore[S]) foldOptimistic() {
+	entries := b.optimistic.entries
+	for idx, entry := range entries {
+		if entry.update == nil {
+			continue
+		}
+		if idx > 0 {
+			b.optimistic.confirmed = entries[idx-1].state
+			b.optimistic.entries = entries[idx:]
+		}
+		return
+	}
+
+	// none pending
+	var zero S
+	b.optimistic.confirmed = zero
+	b.optimistic.entries = nil
+}
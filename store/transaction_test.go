@@ -0,0 +1,230 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_baseStore_Transaction(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name    string
+		policy  ErrorPolicy
+		fn      func(tx Dispatcher) error
+		want    myState
+		wantErr error
+		// notified with BatchAction
+		notified bool
+	}{
+		{
+			name: "committed",
+			fn: func(tx Dispatcher) error {
+				tx.Dispatch(&addAction{"1"})
+				tx.Dispatch(&addAction{"2"})
+				return nil
+			},
+			want:     myState{value: "012"},
+			notified: true,
+		},
+		{
+			name: "rolled back by fn",
+			fn: func(tx Dispatcher) error {
+				tx.Dispatch(&addAction{"1"})
+				return errAbort
+			},
+			want:    myState{value: "0"},
+			wantErr: errAbort,
+		},
+		{
+			name:   "rolled back by reducer",
+			policy: DiscardOnError,
+			fn: func(tx Dispatcher) error {
+				tx.Dispatch(&addAction{"1"})
+				tx.Dispatch(&failAction{"x"})
+				tx.Dispatch(&addAction{"2"})
+				return nil
+			},
+			want:    myState{value: "0"},
+			wantErr: errBadValue,
+		},
+		{
+			name:   "continue on error",
+			policy: ContinueOnError,
+			fn: func(tx Dispatcher) error {
+				tx.Dispatch(&addAction{"1"})
+				tx.Dispatch(&failAction{"x"})
+				return nil
+			},
+			want:     myState{value: "01x"},
+			notified: true,
+		},
+		{
+			name: "panic",
+			fn: func(tx Dispatcher) error {
+				tx.Dispatch(&addAction{"1"})
+				panic("transaction panics")
+			},
+			want:    myState{value: "0"},
+			wantErr: &PanicError{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMyStateStore().AddReducer(failingReducer).SetErrorPolicy(tt.policy).SetPanicHandler(func(err *PanicError) {})
+			b.Dispatch(&setAction{"0"})
+
+			var lock sync.Mutex
+			var actions []Action
+			b.Subscribe(func(state myState, old myState, action Action) {
+				lock.Lock()
+				defer lock.Unlock()
+				actions = append(actions, action)
+			})
+
+			err := b.Transaction(tt.fn)
+			b.Stop()
+			b.WaitForStore()
+
+			var panicErr *PanicError
+			if errors.As(tt.wantErr, &panicErr) {
+				if !errors.As(err, &panicErr) || panicErr.Source != PanicInTransaction {
+					t.Errorf("Transaction: want PanicError got %v", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Transaction: want error %v got %v", tt.wantErr, err)
+			}
+			assertState(t, b.GetState(), tt.want, nil)
+
+			lock.Lock()
+			defer lock.Unlock()
+			// after InitAction
+			notified := len(actions) == 2
+			if notified != tt.notified {
+				t.Errorf("notified: want %v got %v", tt.notified, actions)
+			}
+			if notified {
+				if _, ok := actions[1].(*BatchAction); !ok {
+					t.Errorf("notified: want BatchAction got %T", actions[1])
+				}
+			}
+		})
+	}
+}
+
+func Test_baseStore_DispatchOptimistic(t *testing.T) {
+
+	t.Run("revert rebases the actions after", func(t *testing.T) {
+		b := newMyStateStore()
+
+		var lock sync.Mutex
+		var reverted []Action
+		b.Subscribe(func(state myState, old myState, action Action) {
+			if reified, ok := action.(*RevertAction); ok {
+				lock.Lock()
+				reverted = append(reverted, reified.Action)
+				lock.Unlock()
+			}
+		})
+
+		b.Dispatch(&addAction{"a"})
+		update := b.DispatchOptimistic(&addAction{"x"})
+		b.Dispatch(&addAction{"b"})
+		update.Revert()
+		// ignored after reverted
+		update.Confirm()
+		b.Dispatch(&addAction{"c"})
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "abc"}, nil)
+		lock.Lock()
+		defer lock.Unlock()
+		if len(reverted) != 1 {
+			t.Errorf("RevertAction: want 1 got %v", reverted)
+		}
+	})
+
+	t.Run("confirm keeps the action", func(t *testing.T) {
+		b := newMyStateStore()
+
+		first := b.DispatchOptimistic(&addAction{"x"})
+		second := b.DispatchOptimistic(&addAction{"y"})
+		b.Dispatch(&addAction{"a"})
+		first.Confirm()
+		b.Dispatch(&addAction{"b"})
+		second.Revert()
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "xab"}, nil)
+	})
+
+	t.Run("with transaction", func(t *testing.T) {
+		b := newMyStateStore()
+
+		update := b.DispatchOptimistic(&addAction{"x"})
+		err := b.Transaction(func(tx Dispatcher) error {
+			tx.Dispatch(&addAction{"1"})
+			tx.Dispatch(&addAction{"2"})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Transaction: %v", err)
+		}
+		update.Revert()
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "12"}, nil)
+	})
+	t.Run("confirm does not reduce again", func(t *testing.T) {
+		var reduced int64
+		b := NewStore(myState{}, func(state myState, action Action) (myState, error) {
+			if reified, ok := action.(*addAction); ok {
+				atomic.AddInt64(&reduced, 1)
+				state.value += reified.value
+			}
+			return state, nil
+		})
+
+		update := b.DispatchOptimistic(&addAction{"x"})
+		b.Dispatch(&addAction{"a"})
+		b.Dispatch(&addAction{"b"})
+		update.Confirm()
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "xab"}, nil)
+		if got := atomic.LoadInt64(&reduced); got != 3 {
+			t.Errorf("Confirm: want reduced %d got %d", 3, got)
+		}
+	})
+
+	t.Run("the log is bounded", func(t *testing.T) {
+		b := newMyStateStore()
+
+		update := b.DispatchOptimistic(&addAction{"x"})
+		for idx := 0; idx < optimisticLimit; idx++ {
+			b.Dispatch(&addAction{""})
+		}
+		// confirmed already
+		update.Revert()
+
+		b.Stop()
+		b.WaitForStore()
+
+		assertState(t, b.GetState(), myState{value: "x"}, nil)
+		if got := len(b.(*baseStore[myState]).optimistic.entries); got != 0 {
+			t.Errorf("entries: want %d got %d", 0, got)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+)
+
+// WaitFor waits until the current or a future state satisfies the predicate and returns the state.
+// the predicate is called in the dispatch context starting with the current state, so no state is missed.
+// returns ctx.Err() when ctx is done, ErrStoreStopped when the store stopped before satisfied.
+func WaitFor[S State](ctx context.Context, store Store[S], predicate func(state S) bool) (S, error) {
+	var zero S
+	if store == nil {
+		return zero, ErrStoreStopped
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	satisfied := make(chan S, 1)
+	disposer := store.SubscribeContext(ctx, nil, func(newState S, oldState S, action Action) {
+		if !predicate(newState) {
+			return
+		}
+		select {
+		case satisfied <- newState:
+			// not to evaluate further
+			cancel()
+		default:
+		}
+	})
+	defer disposer.Dispose()
+
+	var err error
+	select {
+	case state := <-satisfied:
+		return state, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-store.Done():
+		err = ErrStoreStopped
+	}
+
+	// satisfied at the same time
+	select {
+	case state := <-satisfied:
+		return state, nil
+	default:
+		return zero, err
+	}
+}
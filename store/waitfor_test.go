@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WaitFor(t *testing.T) {
+	contains := func(value string) func(state myState) bool {
+		return func(state myState) bool {
+			return strings.Contains(state.value, value)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		before    []Action
+		actions   []Action
+		predicate func(state myState) bool
+		timeout   time.Duration
+		stop      bool
+		want      myState
+		wantErr   error
+	}{
+		{
+			name:      "satisfied already",
+			before:    []Action{&addAction{"1"}},
+			predicate: contains("1"),
+			timeout:   time.Second,
+			want:      myState{value: "1"},
+		},
+		{
+			name:      "satisfied later",
+			actions:   []Action{&addAction{"1"}, &addAction{"2"}, &addAction{"3"}},
+			predicate: contains("2"),
+			timeout:   time.Second,
+			want:      myState{value: "12"},
+		},
+		{
+			name:      "timeout",
+			actions:   []Action{&addAction{"1"}},
+			predicate: contains("2"),
+			timeout:   50 * time.Millisecond,
+			wantErr:   context.DeadlineExceeded,
+		},
+		{
+			name:      "store stopped",
+			actions:   []Action{&addAction{"1"}},
+			predicate: contains("2"),
+			timeout:   time.Second,
+			stop:      true,
+			wantErr:   ErrStoreStopped,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMyStateStore()
+			defer b.WaitForStore()
+			defer b.Stop()
+
+			for _, action := range tt.before {
+				if _, _, err := b.DispatchSync(context.Background(), action); err != nil {
+					t.Fatalf("DispatchSync: %v", err)
+				}
+			}
+
+			// dispatched while waiting
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				for _, action := range tt.actions {
+					b.Dispatch(action)
+				}
+				if tt.stop {
+					b.Stop()
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			got, err := WaitFor(ctx, b, tt.predicate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WaitFor: want error %v got %v", tt.wantErr, err)
+			}
+			assertState(t, got, tt.want, nil)
+		})
+	}
+}